@@ -0,0 +1,290 @@
+// library_example.go - Example implementation of the library scanner
+// This is a conceptual example showing how to replace the filename-only
+// song listing with a real metadata-aware library scan.
+
+package main
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dhowden/tag"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ScanStatus is the lifecycle of a library scan job.
+type ScanStatus string
+
+const (
+	ScanPending ScanStatus = "pending"
+	ScanRunning ScanStatus = "running"
+	ScanDone    ScanStatus = "done"
+	ScanFailed  ScanStatus = "failed"
+)
+
+// ScanJob tracks the progress of a single library scan.
+type ScanJob struct {
+	ID        string     `json:"id"`
+	Status    ScanStatus `json:"status"`
+	Scanned   int        `json:"scanned"`
+	Added     int        `json:"added"`
+	Updated   int        `json:"updated"`
+	Error     string     `json:"error,omitempty"`
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   time.Time  `json:"ended_at,omitempty"`
+}
+
+// Library walks one or more root directories, extracts tags, and persists
+// rich song rows to the database. It also emits hub events so connected
+// clients can refresh as new tracks are discovered.
+type Library struct {
+	Roots []string
+	DB    *gorm.DB
+	Hub   *Hub
+
+	mu   sync.Mutex
+	jobs map[string]*ScanJob
+}
+
+// NewLibrary creates a Library scanning the given root directories.
+func NewLibrary(db *gorm.DB, hub *Hub, roots ...string) *Library {
+	if len(roots) == 0 {
+		roots = []string{"songs"}
+	}
+	return &Library{
+		Roots: roots,
+		DB:    db,
+		Hub:   hub,
+		jobs:  make(map[string]*ScanJob),
+	}
+}
+
+// StartScan kicks off an incremental, idempotent scan in the background and
+// returns immediately with a job ID that can be polled for progress.
+func (l *Library) StartScan() *ScanJob {
+	job := &ScanJob{
+		ID:        uuid.NewString(),
+		Status:    ScanPending,
+		StartedAt: time.Now(),
+	}
+
+	l.mu.Lock()
+	l.jobs[job.ID] = job
+	l.mu.Unlock()
+
+	go l.runScan(job)
+
+	return job
+}
+
+// Job returns the scan job with the given ID, if any.
+func (l *Library) Job(id string) (*ScanJob, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	job, ok := l.jobs[id]
+	return job, ok
+}
+
+func (l *Library) runScan(job *ScanJob) {
+	job.Status = ScanRunning
+
+	for _, root := range l.Roots {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			if !isAudioFile(path) {
+				return nil
+			}
+
+			job.Scanned++
+			added, updated, err := l.scanFile(path)
+			if err != nil {
+				log.Printf("library: skipping %s: %v", path, err)
+				return nil
+			}
+			if added {
+				job.Added++
+			}
+			if updated {
+				job.Updated++
+			}
+			return nil
+		})
+		if err != nil {
+			job.Status = ScanFailed
+			job.Error = err.Error()
+			job.EndedAt = time.Now()
+			return
+		}
+	}
+
+	job.Status = ScanDone
+	job.EndedAt = time.Now()
+}
+
+// songsRoot is the directory Song.File paths are stored relative to
+// throughout the codebase (main.go's songLookupByFile, stream_example.go's
+// Stream, playlist_formats_example.go's ImportOptions.SongsDir).
+const songsRoot = "songs"
+
+// scanFile reads tags for a single file and upserts it, skipping files whose
+// mtime hasn't changed since the last scan (incremental rescans).
+func (l *Library) scanFile(absPath string) (added bool, updated bool, err error) {
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return false, false, err
+	}
+
+	relPath := absPath
+	if rel, err := filepath.Rel(songsRoot, absPath); err == nil {
+		relPath = rel
+	}
+
+	var existing Song
+	found := l.DB.Where("file = ?", relPath).First(&existing).Error == nil
+	if found && !info.ModTime().After(existing.ModTime) {
+		return false, false, nil
+	}
+
+	f, err := os.Open(absPath)
+	if err != nil {
+		return false, false, err
+	}
+	defer f.Close()
+
+	hash, err := hashAudioFrames(f)
+	if err != nil {
+		return false, false, err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return false, false, err
+	}
+	meta, _ := tag.ReadFrom(f) // metadata is best-effort; absence isn't fatal
+
+	song := songFromTag(relPath, hash, info.ModTime(), meta)
+
+	if found {
+		song.ID = existing.ID
+		if err := l.DB.Save(&song).Error; err != nil {
+			return false, false, err
+		}
+		l.notify("song_updated", song)
+		return false, true, nil
+	}
+
+	// content hash match elsewhere means the file moved rather than being new
+	var moved Song
+	if l.DB.Where("hash = ?", hash).First(&moved).Error == nil {
+		moved.File = relPath
+		moved.ModTime = info.ModTime()
+		if err := l.DB.Save(&moved).Error; err != nil {
+			return false, false, err
+		}
+		l.notify("song_moved", moved)
+		return false, true, nil
+	}
+
+	if err := l.DB.Create(&song).Error; err != nil {
+		return false, false, err
+	}
+	l.notify("song_added", song)
+	return true, false, nil
+}
+
+func songFromTag(path, hash string, modTime time.Time, meta tag.Metadata) Song {
+	song := Song{
+		File:    path,
+		Hash:    hash,
+		ModTime: modTime,
+	}
+
+	if meta == nil {
+		song.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		return song
+	}
+
+	song.Name = meta.Title()
+	song.Artist = meta.Artist()
+	song.Album = meta.Album()
+	song.AlbumArtist = meta.AlbumArtist()
+	song.Year = meta.Year()
+	song.Genre = meta.Genre()
+	track, _ := meta.Track()
+	song.TrackNo = track
+	disc, _ := meta.Disc()
+	song.DiscNo = disc
+
+	if song.Name == "" {
+		song.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	if pic := meta.Picture(); pic != nil {
+		song.CoverArtPath = coverArtPathFor(path)
+		if err := os.WriteFile(song.CoverArtPath, pic.Data, 0644); err != nil {
+			log.Printf("library: writing cover art for %s: %v", path, err)
+			song.CoverArtPath = ""
+		}
+	}
+
+	return song
+}
+
+func coverArtPathFor(audioPath string) string {
+	sum := sha1.Sum([]byte(audioPath))
+	return filepath.Join("covers", fmt.Sprintf("%x.jpg", sum))
+}
+
+// hashAudioFrames computes a SHA-1 over the audio data (via tag.Sum) so moved
+// or duplicated files can be recognized across rescans regardless of path.
+// ID3v1, ID3v2 and MP4 metadata are left out of the sum; for other formats
+// the whole file is hashed.
+func hashAudioFrames(r io.ReadSeeker) (string, error) {
+	return tag.Sum(r)
+}
+
+func isAudioFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp3", ".flac", ".ogg", ".m4a", ".wav":
+		return true
+	default:
+		return false
+	}
+}
+
+func (l *Library) notify(event string, song Song) {
+	if l.Hub == nil {
+		return
+	}
+	SendLibraryEvent(l.Hub, event, song)
+}
+
+// scanLibraryHandler handles POST /api/library/scan
+func scanLibraryHandler(lib *Library) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		job := lib.StartScan()
+		return c.JSON(fiber.Map{"scan_id": job.ID})
+	}
+}
+
+// scanStatusHandler handles GET /api/library/scan/:id
+func scanStatusHandler(lib *Library) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		job, ok := lib.Job(c.Params("id"))
+		if !ok {
+			return c.Status(404).JSON(fiber.Map{"error": "Scan job not found"})
+		}
+		return c.JSON(job)
+	}
+}
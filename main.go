@@ -1,120 +1,131 @@
 package main
 
 import (
-	"encoding/json"
-	"fmt"
-	"io/fs"
 	"log"
-	"net/http"
 	"os"
-	"path/filepath"
-	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/logger"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
-
-
-type Song struct {
-	Name string `json:"name"`
-	File string `json:"file"`
-}
-
 func main() {
-	// Create songs directory if it doesn't exist
-	if err := os.MkdirAll("songs", 0755); err != nil {
-		log.Fatal(err)
+	for _, dir := range []string{"songs", "static", "podcasts", "covers", transcodeCacheDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			log.Fatal(err)
+		}
 	}
 
-	// Create static directory if it doesn't exist
-	if err := os.MkdirAll("static", 0755); err != nil {
-		log.Fatal(err)
+	db, err := gorm.Open(sqlite.Open("music.db"), &gorm.Config{})
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	if err := db.AutoMigrate(
+		&Song{}, &Playlist{}, &PlaylistSong{},
+		&UserCredential{}, &QueuedScrobbleRow{},
+		&Podcast{}, &PodcastEpisode{}, &EpisodePosition{},
+	); err != nil {
+		log.Fatal("Failed to migrate schema:", err)
 	}
 
-	// Serve static files
-	http.Handle("/", http.FileServer(http.Dir("static/")))
+	hub := NewHub()
+	go hub.Run()
 
-	// API endpoint to list songs
-	http.HandleFunc("/songs", listSongsHandler)
+	lib := NewLibrary(db, hub, "songs")
 
-	// API endpoint to stream a song
-	http.HandleFunc("/song/", streamSongHandler)
+	queue := &ScrobbleQueue{DB: db, Scrobblers: scrobblerBackends()}
+	tracker := &PlaybackTracker{DB: db, Scrobblers: queue.Scrobblers, Queue: queue}
+	hub.Tracker = tracker
+	hub.LookupTrack = songLookupTrack(db)
+	go retryScrobblesPeriodically(queue)
 
-	fmt.Println("Server starting on :8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
-}
+	podcasts := NewPodcastManager(db, hub, 0)
+	go podcasts.Run(make(chan struct{}))
 
-func listSongsHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+	app := fiber.New()
+	app.Use(logger.New())
+	app.Use(cors.New())
 
-	var songs []Song
+	// Static assets
+	app.Static("/", "static")
 
-	// Read songs directory
-	err := filepath.WalkDir("songs", func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
+	// Songs and playlists
+	app.Get("/api/songs", getSongs(db))
+	app.Post("/api/songs", createSong(db))
 
-		// Skip directories
-		if d.IsDir() {
-			return nil
-		}
+	app.Get("/api/playlists", getPlaylists(db))
+	app.Post("/api/playlists", createPlaylist(db))
+	app.Get("/api/playlists/:id", getPlaylist(db))
+	app.Put("/api/playlists/:id", updatePlaylist(db))
+	app.Delete("/api/playlists/:id", deletePlaylist(db))
+	app.Post("/api/playlists/:id/songs", addSongToPlaylist(db))
+	app.Delete("/api/playlists/:id/songs/:songId", removeSongFromPlaylist(db))
 
-		// Only include audio files
-		ext := strings.ToLower(filepath.Ext(path))
-		if ext == ".mp3" || ext == ".wav" || ext == ".ogg" || ext == ".flac" {
-			relPath, _ := filepath.Rel("songs", path)
-			song := Song{
-				Name: strings.TrimSuffix(relPath, ext),
-				File: relPath,
-			}
-			songs = append(songs, song)
-		}
+	// Playlist import/export
+	app.Post("/api/playlists/import", importPlaylistHandler(db))
+	app.Get("/api/playlists/:id/export", exportPlaylistHandler(db))
 
-		return nil
-	})
+	// Library scanning
+	app.Post("/api/library/scan", scanLibraryHandler(lib))
+	app.Get("/api/library/scan/:id", scanStatusHandler(lib))
 
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
+	// Streaming
+	app.Get("/api/stream/:id", streamSongFiberHandler(songLookupByID(db)))
+	app.Get("/song/*", adaptor.HTTPHandlerFunc(streamSongHandlerV2(songLookupByFile(db))))
 
-	json.NewEncoder(w).Encode(songs)
-}
+	// Scrobbling
+	app.Post("/api/listens", listensHandler(tracker))
 
-func streamSongHandler(w http.ResponseWriter, r *http.Request) {
-	// Extract filename from URL path
-	filePath := strings.TrimPrefix(r.URL.Path, "/song/")
+	// Subsonic-compatible API, for native clients (DSub, Symfonium, ...)
+	RegisterSubsonicRoutes(app, db, tracker)
 
-	// Security check to prevent directory traversal
-	if strings.Contains(filePath, "..") {
-		http.Error(w, "Invalid file path", http.StatusBadRequest)
-		return
-	}
+	// Podcast subscriptions, downloads, and resume positions
+	RegisterPodcastRoutes(app, podcasts, db)
 
-	// Full path to the file
-	fullPath := filepath.Join("songs", filePath)
+	// WebSocket sync
+	app.Post("/api/sync/play", syncPlayHandler(hub))
+	app.Use("/ws", websocketHandler)
+	app.Get("/ws/:user_id", ServeWebSocket(hub))
 
-	// Check if file exists
-	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
-		http.Error(w, "File not found", http.StatusNotFound)
-		return
-	}
+	log.Fatal(app.Listen(":8080"))
+}
 
-	// Set appropriate content type
-	ext := strings.ToLower(filepath.Ext(fullPath))
-	switch ext {
-	case ".mp3":
-		w.Header().Set("Content-Type", "audio/mpeg")
-	case ".wav":
-		w.Header().Set("Content-Type", "audio/wav")
-	case ".ogg":
-		w.Header().Set("Content-Type", "audio/ogg")
-	case ".flac":
-		w.Header().Set("Content-Type", "audio/flac")
-	default:
-		w.Header().Set("Content-Type", "application/octet-stream")
+// songLookupByID resolves a Song by its primary key, for the Fiber-native
+// stream route (/api/stream/:id).
+func songLookupByID(db *gorm.DB) func(id string) (StreamSong, bool) {
+	return func(id string) (StreamSong, bool) {
+		var song Song
+		if err := db.First(&song, id).Error; err != nil {
+			return StreamSong{}, false
+		}
+		return StreamSong{ID: song.ID, File: song.File, Duration: song.Duration}, true
 	}
+}
 
+// songLookupByFile resolves a Song by its stored file path, for the legacy
+// /song/ route that historically addressed songs by filename.
+func songLookupByFile(db *gorm.DB) func(file string) (StreamSong, bool) {
+	return func(file string) (StreamSong, bool) {
+		var song Song
+		if err := db.Where("file = ?", file).First(&song).Error; err != nil {
+			return StreamSong{}, false
+		}
+		return StreamSong{ID: song.ID, File: song.File, Duration: song.Duration}, true
+	}
+}
 
-	// Stream the file
-	http.ServeFile(w, r, fullPath)
+// songLookupTrack adapts the Song table to the Hub's LookupTrack hook so
+// WebSocket play/pause messages can be fed into the scrobbler.
+func songLookupTrack(db *gorm.DB) func(songID string) (Track, bool) {
+	return func(songID string) (Track, bool) {
+		var song Song
+		if err := db.First(&song, songID).Error; err != nil {
+			return Track{}, false
+		}
+		return Track{Artist: song.Artist, Title: song.Name, Album: song.Album, Duration: time.Duration(song.Duration) * time.Second}, true
+	}
 }
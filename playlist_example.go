@@ -5,20 +5,31 @@ package main
 
 import (
 	"encoding/json"
-	"log"
+	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-// Song represents a music file
+// Song represents a music file, enriched with tag-derived metadata by the
+// library scanner (see library_example.go) as it's discovered.
 type Song struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Name      string    `json:"name"`
-	File      string    `json:"file"`
-	CreatedAt time.Time `json:"created_at"`
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	Name         string    `json:"name"`
+	Artist       string    `json:"artist"`
+	Album        string    `json:"album"`
+	AlbumArtist  string    `json:"album_artist"`
+	Year         int       `json:"year"`
+	Genre        string    `json:"genre"`
+	Duration     int       `json:"duration"` // seconds
+	TrackNo      int       `json:"track_no"`
+	DiscNo       int       `json:"disc_no"`
+	CoverArtPath string    `json:"cover_art_path"`
+	File         string    `json:"file"`
+	Hash         string    `gorm:"index" json:"hash"` // sha1 of the audio data (see hashAudioFrames), used to detect moved/duplicate files
+	ModTime      time.Time `json:"mod_time"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 // Playlist represents a collection of songs
@@ -39,36 +50,6 @@ type PlaylistSong struct {
 	Position   int  `gorm:"default:0"`
 }
 
-func main() {
-	// Initialize Fiber app
-	app := fiber.New()
-
-	// Initialize database
-	db, err := gorm.Open(sqlite.Open("music.db"), &gorm.Config{})
-	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
-	}
-
-	// Migrate the schema
-	db.AutoMigrate(&Song{}, &Playlist{}, &PlaylistSong{})
-
-	// API Routes
-	app.Get("/api/songs", getSongs(db))
-	app.Post("/api/songs", createSong(db))
-
-	app.Get("/api/playlists", getPlaylists(db))
-	app.Post("/api/playlists", createPlaylist(db))
-	app.Get("/api/playlists/:id", getPlaylist(db))
-	app.Put("/api/playlists/:id", updatePlaylist(db))
-	app.Delete("/api/playlists/:id", deletePlaylist(db))
-
-	app.Post("/api/playlists/:id/songs", addSongToPlaylist(db))
-	app.Delete("/api/playlists/:id/songs/:songId", removeSongFromPlaylist(db))
-
-	// Start server
-	log.Fatal(app.Listen(":8080"))
-}
-
 // getSongs returns all songs
 func getSongs(db *gorm.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -160,7 +141,10 @@ func deletePlaylist(db *gorm.DB) fiber.Handler {
 // addSongToPlaylist adds a song to a playlist
 func addSongToPlaylist(db *gorm.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		playlistID := c.Params("id")
+		playlistID, err := strconv.ParseUint(c.Params("id"), 10, 64)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid playlist id"})
+		}
 
 		// Parse request body for song ID
 		var requestData map[string]interface{}
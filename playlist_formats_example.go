@@ -0,0 +1,361 @@
+// playlist_formats_example.go - Example implementation of playlist import/export
+// This is a conceptual example showing how to extend the current music player
+// with support for the common on-disk playlist formats (M3U/M3U8, PLS, XSPF).
+
+package main
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// ImportOptions controls how unmatched playlist entries are handled
+type ImportOptions struct {
+	// AutoCreateMissing creates a Song row for entries that don't match an
+	// existing song instead of skipping them.
+	AutoCreateMissing bool
+	// SongsDir is the directory relative paths are resolved against.
+	SongsDir string
+}
+
+// playlistEntry is an intermediate representation of one parsed line,
+// shared by all three import formats before they're resolved to Songs.
+type playlistEntry struct {
+	Path     string
+	Title    string
+	Artist   string
+	Duration int
+}
+
+// ImportFromM3U parses an M3U/M3U8 playlist (extended or plain) and returns
+// a Playlist populated with matching or newly created Songs.
+func ImportFromM3U(db *gorm.DB, r io.Reader, name string, opts ImportOptions) (*Playlist, error) {
+	var entries []playlistEntry
+	var pending playlistEntry
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line == "#EXTM3U" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#EXTINF:") {
+			pending = parseExtInf(line)
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			continue // unknown directive, ignore
+		}
+
+		pending.Path = line
+		entries = append(entries, pending)
+		pending = playlistEntry{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading m3u: %w", err)
+	}
+
+	return buildPlaylist(db, name, entries, opts)
+}
+
+// parseExtInf parses "#EXTINF:duration,artist - title" into a playlistEntry.
+func parseExtInf(line string) playlistEntry {
+	rest := strings.TrimPrefix(line, "#EXTINF:")
+	commaIdx := strings.Index(rest, ",")
+	if commaIdx == -1 {
+		return playlistEntry{}
+	}
+
+	duration, _ := strconv.Atoi(strings.TrimSpace(rest[:commaIdx]))
+	info := rest[commaIdx+1:]
+
+	entry := playlistEntry{Duration: duration}
+	if parts := strings.SplitN(info, " - ", 2); len(parts) == 2 {
+		entry.Artist = strings.TrimSpace(parts[0])
+		entry.Title = strings.TrimSpace(parts[1])
+	} else {
+		entry.Title = strings.TrimSpace(info)
+	}
+	return entry
+}
+
+// ImportFromPLS parses a PLS playlist ([playlist] section with FileN/TitleN/LengthN).
+func ImportFromPLS(db *gorm.DB, r io.Reader, name string, opts ImportOptions) (*Playlist, error) {
+	files := map[int]string{}
+	titles := map[int]string{}
+	lengths := map[int]int{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch {
+		case strings.HasPrefix(key, "file"):
+			idx, _ := strconv.Atoi(strings.TrimPrefix(key, "file"))
+			files[idx] = value
+		case strings.HasPrefix(key, "title"):
+			idx, _ := strconv.Atoi(strings.TrimPrefix(key, "title"))
+			titles[idx] = value
+		case strings.HasPrefix(key, "length"):
+			idx, _ := strconv.Atoi(strings.TrimPrefix(key, "length"))
+			lengths[idx], _ = strconv.Atoi(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading pls: %w", err)
+	}
+
+	var entries []playlistEntry
+	for idx, path := range files {
+		entries = append(entries, playlistEntry{
+			Path:     path,
+			Title:    titles[idx],
+			Duration: lengths[idx],
+		})
+	}
+
+	return buildPlaylist(db, name, entries, opts)
+}
+
+// xspfRoot mirrors the subset of the XSPF schema this importer understands.
+type xspfRoot struct {
+	TrackList struct {
+		Track []struct {
+			Location string `xml:"location"`
+			Title    string `xml:"title"`
+			Creator  string `xml:"creator"`
+			Duration int    `xml:"duration"` // milliseconds per spec
+		} `xml:"track"`
+	} `xml:"trackList"`
+}
+
+// ImportFromXSPF parses an XSPF (XML Shareable Playlist Format) document.
+func ImportFromXSPF(db *gorm.DB, r io.Reader, name string, opts ImportOptions) (*Playlist, error) {
+	var doc xspfRoot
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("reading xspf: %w", err)
+	}
+
+	entries := make([]playlistEntry, 0, len(doc.TrackList.Track))
+	for _, t := range doc.TrackList.Track {
+		path := strings.TrimPrefix(t.Location, "file://")
+		entries = append(entries, playlistEntry{
+			Path:     path,
+			Title:    t.Title,
+			Artist:   t.Creator,
+			Duration: t.Duration / 1000,
+		})
+	}
+
+	return buildPlaylist(db, name, entries, opts)
+}
+
+// buildPlaylist resolves parsed entries against existing Songs (by file path,
+// relative to opts.SongsDir), optionally auto-creating missing ones, and
+// persists the resulting Playlist.
+func buildPlaylist(db *gorm.DB, name string, entries []playlistEntry, opts ImportOptions) (*Playlist, error) {
+	playlist := &Playlist{Name: name}
+
+	for _, entry := range entries {
+		if entry.Path == "" {
+			continue
+		}
+
+		relPath := entry.Path
+		if !filepath.IsAbs(relPath) {
+			relPath = filepath.Clean(relPath)
+		} else if opts.SongsDir != "" {
+			if rel, err := filepath.Rel(opts.SongsDir, relPath); err == nil {
+				relPath = rel
+			}
+		}
+
+		var song Song
+		err := db.Where("file = ?", relPath).First(&song).Error
+		switch {
+		case err == nil:
+			// matched existing song
+		case opts.AutoCreateMissing:
+			song = Song{
+				Name:     entry.Title,
+				Artist:   entry.Artist,
+				File:     relPath,
+				Duration: entry.Duration,
+			}
+			if song.Name == "" {
+				song.Name = strings.TrimSuffix(filepath.Base(relPath), filepath.Ext(relPath))
+			}
+			if err := db.Create(&song).Error; err != nil {
+				return nil, fmt.Errorf("creating song for %q: %w", relPath, err)
+			}
+		default:
+			continue // skip entries with no matching song
+		}
+
+		playlist.Songs = append(playlist.Songs, song)
+	}
+
+	if err := db.Create(playlist).Error; err != nil {
+		return nil, fmt.Errorf("creating playlist: %w", err)
+	}
+
+	return playlist, nil
+}
+
+// ExportAsM3U writes the playlist as extended M3U.
+func (p *Playlist) ExportAsM3U(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "#EXTM3U")
+	for _, song := range p.Songs {
+		fmt.Fprintf(bw, "#EXTINF:%d,%s - %s\n", song.Duration, song.Artist, song.Name)
+		fmt.Fprintln(bw, song.File)
+	}
+	return bw.Flush()
+}
+
+// ExportAsPLS writes the playlist in the PLS format.
+func (p *Playlist) ExportAsPLS(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "[playlist]")
+	for i, song := range p.Songs {
+		n := i + 1
+		fmt.Fprintf(bw, "File%d=%s\n", n, song.File)
+		fmt.Fprintf(bw, "Title%d=%s\n", n, song.Name)
+		fmt.Fprintf(bw, "Length%d=%d\n", n, song.Duration)
+	}
+	fmt.Fprintf(bw, "NumberOfEntries=%d\n", len(p.Songs))
+	fmt.Fprintln(bw, "Version=2")
+	return bw.Flush()
+}
+
+// ExportAsXSPF writes the playlist as an XSPF document.
+func (p *Playlist) ExportAsXSPF(w io.Writer) error {
+	type track struct {
+		Location string `xml:"location"`
+		Title    string `xml:"title"`
+		Creator  string `xml:"creator"`
+		Duration int    `xml:"duration"`
+	}
+	doc := struct {
+		XMLName   xml.Name `xml:"playlist"`
+		Version   string   `xml:"version,attr"`
+		Xmlns     string   `xml:"xmlns,attr"`
+		Title     string   `xml:"title"`
+		TrackList struct {
+			Track []track `xml:"track"`
+		} `xml:"trackList"`
+	}{
+		Version: "1",
+		Xmlns:   "http://xspf.org/ns/0/",
+		Title:   p.Name,
+	}
+
+	for _, song := range p.Songs {
+		doc.TrackList.Track = append(doc.TrackList.Track, track{
+			Location: "file://" + song.File,
+			Title:    song.Name,
+			Creator:  song.Artist,
+			Duration: song.Duration * 1000,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(doc)
+}
+
+// importPlaylistHandler handles POST /api/playlists/import?format=m3u|pls|xspf
+func importPlaylistHandler(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		format := strings.ToLower(c.Query("format", "m3u"))
+		autoCreate := c.Query("auto_create") == "true"
+
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Missing playlist file"})
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Cannot open uploaded file"})
+		}
+		defer file.Close()
+
+		name := strings.TrimSuffix(fileHeader.Filename, filepath.Ext(fileHeader.Filename))
+		opts := ImportOptions{AutoCreateMissing: autoCreate, SongsDir: "songs"}
+
+		playlist, err := importPlaylist(db, format, file, name, opts)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return c.JSON(playlist)
+	}
+}
+
+func importPlaylist(db *gorm.DB, format string, file multipart.File, name string, opts ImportOptions) (*Playlist, error) {
+	switch format {
+	case "m3u", "m3u8":
+		return ImportFromM3U(db, file, name, opts)
+	case "pls":
+		return ImportFromPLS(db, file, name, opts)
+	case "xspf":
+		return ImportFromXSPF(db, file, name, opts)
+	default:
+		return nil, fmt.Errorf("unsupported playlist format: %s", format)
+	}
+}
+
+// exportPlaylistHandler handles GET /api/playlists/:id/export?format=m3u|pls|xspf
+func exportPlaylistHandler(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		format := strings.ToLower(c.Query("format", "m3u"))
+
+		var playlist Playlist
+		if err := db.Preload("Songs").First(&playlist, id).Error; err != nil {
+			return c.Status(404).JSON(fiber.Map{"error": "Playlist not found"})
+		}
+
+		var contentType, filename string
+		var export func(io.Writer) error
+
+		switch format {
+		case "m3u", "m3u8":
+			contentType, filename = "audio/x-mpegurl", playlist.Name+".m3u8"
+			export = playlist.ExportAsM3U
+		case "pls":
+			contentType, filename = "audio/x-scpls", playlist.Name+".pls"
+			export = playlist.ExportAsPLS
+		case "xspf":
+			contentType, filename = "application/xspf+xml", playlist.Name+".xspf"
+			export = playlist.ExportAsXSPF
+		default:
+			return c.Status(400).JSON(fiber.Map{"error": "unsupported playlist format: " + format})
+		}
+
+		c.Set("Content-Type", contentType)
+		c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		return export(c.Response().BodyWriter())
+	}
+}
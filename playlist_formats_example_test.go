@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseExtInf(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantArtist string
+		wantTitle  string
+		wantDur    int
+	}{
+		{
+			name:       "artist and title",
+			line:       "#EXTINF:213,Pink Floyd - Time",
+			wantArtist: "Pink Floyd",
+			wantTitle:  "Time",
+			wantDur:    213,
+		},
+		{
+			name:      "title only",
+			line:      "#EXTINF:180,Intro",
+			wantTitle: "Intro",
+			wantDur:   180,
+		},
+		{
+			name: "missing comma yields empty entry",
+			line: "#EXTINF:180",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := parseExtInf(tt.line)
+			if entry.Artist != tt.wantArtist || entry.Title != tt.wantTitle || entry.Duration != tt.wantDur {
+				t.Errorf("parseExtInf(%q) = %+v, want artist=%q title=%q duration=%d",
+					tt.line, entry, tt.wantArtist, tt.wantTitle, tt.wantDur)
+			}
+		})
+	}
+}
+
+func TestExportAsM3U(t *testing.T) {
+	playlist := &Playlist{
+		Name: "Road Trip",
+		Songs: []Song{
+			{Name: "Time", Artist: "Pink Floyd", File: "pink-floyd/time.flac", Duration: 413},
+		},
+	}
+
+	var buf strings.Builder
+	if err := playlist.ExportAsM3U(&buf); err != nil {
+		t.Fatalf("ExportAsM3U returned error: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"#EXTM3U", "#EXTINF:413,Pink Floyd - Time", "pink-floyd/time.flac"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("ExportAsM3U output missing %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestExportAsPLS(t *testing.T) {
+	playlist := &Playlist{
+		Name:  "Road Trip",
+		Songs: []Song{{Name: "Time", File: "pink-floyd/time.flac", Duration: 413}},
+	}
+
+	var buf strings.Builder
+	if err := playlist.ExportAsPLS(&buf); err != nil {
+		t.Fatalf("ExportAsPLS returned error: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"[playlist]", "File1=pink-floyd/time.flac", "Title1=Time", "Length1=413", "NumberOfEntries=1"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("ExportAsPLS output missing %q, got:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,599 @@
+// podcast_example.go - Example implementation of podcast subscriptions
+// This is a conceptual example showing how to add podcast feed subscriptions
+// and resumable episode downloads alongside the existing local-file library.
+
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"gorm.io/gorm"
+)
+
+// Podcast is a subscribed RSS podcast feed.
+type Podcast struct {
+	ID          uint             `gorm:"primaryKey" json:"id"`
+	FeedURL     string           `gorm:"uniqueIndex" json:"feed_url"`
+	Title       string           `json:"title"`
+	ImageURL    string           `json:"image_url"`
+	LastChecked time.Time        `json:"last_checked"`
+	Episodes    []PodcastEpisode `json:"episodes,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// PodcastEpisode is a single episode of a subscribed podcast.
+type PodcastEpisode struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	PodcastID    uint      `gorm:"index" json:"podcast_id"`
+	GUID         string    `gorm:"uniqueIndex" json:"guid"`
+	Title        string    `json:"title"`
+	PubDate      time.Time `json:"pub_date"`
+	EnclosureURL string    `json:"enclosure_url"`
+	LocalFile    string    `json:"local_file,omitempty"`
+	Downloaded   bool      `json:"downloaded"`
+	DurationSec  int       `json:"duration_seconds"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// EpisodePosition tracks per-user resume position for an episode so
+// "resume where you left off" works across devices.
+type EpisodePosition struct {
+	UserID           string    `gorm:"primaryKey" json:"user_id"`
+	PodcastEpisodeID uint      `gorm:"primaryKey" json:"podcast_episode_id"`
+	PositionSeconds  float64   `json:"position_seconds"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// --- Feed parsing ----------------------------------------------------------
+
+// rssFeed covers the subset of RSS 2.0 plus common iTunes extensions that
+// podcast feeds actually use in practice.
+type rssFeed struct {
+	Channel struct {
+		Title string `xml:"title"`
+		Image struct {
+			URL string `xml:"url"`
+		} `xml:"image"`
+		ITunesImage struct {
+			Href string `xml:"href,attr"`
+		} `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+		Items []struct {
+			GUID      string `xml:"guid"`
+			Title     string `xml:"title"`
+			PubDate   string `xml:"pubDate"`
+			Enclosure struct {
+				URL string `xml:"url,attr"`
+			} `xml:"enclosure"`
+			ITunesDuration string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+// ParsedEpisode is one feed item after parsing, before it's matched against
+// existing PodcastEpisode rows.
+type ParsedEpisode struct {
+	GUID         string
+	Title        string
+	PubDate      time.Time
+	EnclosureURL string
+	DurationSec  int
+}
+
+// ParseFeed parses an RSS 2.0 podcast feed into a title, image URL, and
+// the list of episodes it currently advertises. Atom feeds are not parsed:
+// a document without an RSS <channel> yields no title and no episodes.
+func ParseFeed(r io.Reader) (title, imageURL string, episodes []ParsedEpisode, err error) {
+	var feed rssFeed
+	if err := xml.NewDecoder(r).Decode(&feed); err != nil {
+		return "", "", nil, fmt.Errorf("parsing podcast feed: %w", err)
+	}
+
+	title = feed.Channel.Title
+	imageURL = feed.Channel.Image.URL
+	if imageURL == "" {
+		imageURL = feed.Channel.ITunesImage.Href
+	}
+
+	for _, item := range feed.Channel.Items {
+		if item.Enclosure.URL == "" {
+			continue // not a playable episode
+		}
+
+		episodes = append(episodes, ParsedEpisode{
+			GUID:         firstNonEmpty(item.GUID, item.Enclosure.URL),
+			Title:        item.Title,
+			PubDate:      parseFeedDate(item.PubDate),
+			EnclosureURL: item.Enclosure.URL,
+			DurationSec:  parseITunesDuration(item.ITunesDuration),
+		})
+	}
+
+	return title, imageURL, episodes, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// parseFeedDate tries the RSS/RFC1123 layouts feeds commonly use.
+func parseFeedDate(raw string) time.Time {
+	layouts := []string{time.RFC1123Z, time.RFC1123, time.RFC3339}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// parseITunesDuration accepts both "HH:MM:SS" and plain-seconds forms.
+func parseITunesDuration(raw string) int {
+	if raw == "" {
+		return 0
+	}
+	parts := strings.Split(raw, ":")
+	seconds := 0
+	for _, p := range parts {
+		var n int
+		fmt.Sscanf(p, "%d", &n)
+		seconds = seconds*60 + n
+	}
+	return seconds
+}
+
+// --- Subscription management -----------------------------------------------
+
+// PodcastManager owns polling subscribed feeds and downloading episodes.
+type PodcastManager struct {
+	DB       *gorm.DB
+	Hub      *Hub
+	Interval time.Duration
+	Client   *http.Client
+
+	mu sync.Mutex
+}
+
+// NewPodcastManager creates a manager that polls feeds every interval.
+func NewPodcastManager(db *gorm.DB, hub *Hub, interval time.Duration) *PodcastManager {
+	if interval == 0 {
+		interval = time.Hour
+	}
+	return &PodcastManager{DB: db, Hub: hub, Interval: interval, Client: http.DefaultClient}
+}
+
+// Run polls all subscribed feeds on Interval until stop is closed. Callers run
+// this in its own goroutine, mirroring how Hub.Run is started from main.
+func (m *PodcastManager) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.refreshAll()
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (m *PodcastManager) refreshAll() {
+	var podcasts []Podcast
+	m.DB.Find(&podcasts)
+	for _, p := range podcasts {
+		if _, err := m.Refresh(p.ID); err != nil {
+			log.Printf("podcast: refresh failed for %s: %v", p.FeedURL, err)
+		}
+	}
+}
+
+// Subscribe fetches feedURL, creates the Podcast row, and ingests its
+// current episode list.
+func (m *PodcastManager) Subscribe(feedURL string) (*Podcast, error) {
+	resp, err := m.Client.Get(feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetching feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	title, imageURL, episodes, err := ParseFeed(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	podcast := &Podcast{
+		FeedURL:     feedURL,
+		Title:       title,
+		ImageURL:    imageURL,
+		LastChecked: time.Now(),
+	}
+	if err := m.DB.Create(podcast).Error; err != nil {
+		return nil, fmt.Errorf("saving podcast: %w", err)
+	}
+
+	m.ingestEpisodes(podcast.ID, episodes)
+	return podcast, nil
+}
+
+// Refresh re-fetches a podcast's feed and records any new episodes, returning
+// how many were added. It does not start their downloads; DownloadEpisode
+// does that for one episode at a time.
+func (m *PodcastManager) Refresh(podcastID uint) (int, error) {
+	var podcast Podcast
+	if err := m.DB.First(&podcast, podcastID).Error; err != nil {
+		return 0, err
+	}
+
+	resp, err := m.Client.Get(podcast.FeedURL)
+	if err != nil {
+		return 0, fmt.Errorf("fetching feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	_, _, episodes, err := ParseFeed(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	added := m.ingestEpisodes(podcast.ID, episodes)
+
+	podcast.LastChecked = time.Now()
+	m.DB.Save(&podcast)
+
+	return added, nil
+}
+
+func (m *PodcastManager) ingestEpisodes(podcastID uint, episodes []ParsedEpisode) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	added := 0
+	for _, ep := range episodes {
+		var existing PodcastEpisode
+		if m.DB.Where("guid = ?", ep.GUID).First(&existing).Error == nil {
+			continue // already known
+		}
+
+		episode := PodcastEpisode{
+			PodcastID:    podcastID,
+			GUID:         ep.GUID,
+			Title:        ep.Title,
+			PubDate:      ep.PubDate,
+			EnclosureURL: ep.EnclosureURL,
+			DurationSec:  ep.DurationSec,
+		}
+		if err := m.DB.Create(&episode).Error; err != nil {
+			log.Printf("podcast: failed to save episode %s: %v", ep.GUID, err)
+			continue
+		}
+
+		added++
+		if m.Hub != nil {
+			SendLibraryEvent(m.Hub, "podcast_episode_added", episode)
+		}
+	}
+	return added
+}
+
+// DownloadEpisode streams an episode's enclosure into podcasts/<slug>/,
+// resuming from any partial download already on disk and reporting
+// progress via the hub.
+func (m *PodcastManager) DownloadEpisode(episodeID uint) error {
+	var episode PodcastEpisode
+	if err := m.DB.First(&episode, episodeID).Error; err != nil {
+		return err
+	}
+
+	var podcast Podcast
+	if err := m.DB.First(&podcast, episode.PodcastID).Error; err != nil {
+		return err
+	}
+
+	dir := filepath.Join("podcasts", slugify(podcast.Title))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	destPath := filepath.Join(dir, slugify(episode.Title)+filepath.Ext(episode.EnclosureURL))
+
+	var resumeFrom int64
+	if info, err := os.Stat(destPath); err == nil {
+		resumeFrom = info.Size()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, episode.EnclosureURL, nil)
+	if err != nil {
+		return err
+	}
+	if resumeFrom > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", resumeFrom))
+	}
+
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("downloading episode: %w", err)
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	if resp.StatusCode == http.StatusPartialContent {
+		flags |= os.O_APPEND
+	} else {
+		flags |= os.O_TRUNC
+		resumeFrom = 0
+	}
+
+	file, err := os.OpenFile(destPath, flags, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	total := resumeFrom + resp.ContentLength
+	written := resumeFrom
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			if _, err := file.Write(buf[:n]); err != nil {
+				return err
+			}
+			written += int64(n)
+			m.reportProgress(episode, written, total)
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+
+	episode.LocalFile = destPath
+	episode.Downloaded = true
+	return m.DB.Save(&episode).Error
+}
+
+func (m *PodcastManager) reportProgress(episode PodcastEpisode, written, total int64) {
+	if m.Hub == nil {
+		return
+	}
+	SendLibraryEvent(m.Hub, "podcast_download_progress", fiber.Map{
+		"episode_id": episode.ID,
+		"written":    written,
+		"total":      total,
+	})
+}
+
+func slugify(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '-'
+		}
+	}, s)
+	for strings.Contains(s, "--") {
+		s = strings.ReplaceAll(s, "--", "-")
+	}
+	return strings.Trim(s, "-")
+}
+
+// SaveEpisodePosition persists per-user playback position for resume.
+func SaveEpisodePosition(db *gorm.DB, userID string, episodeID uint, position float64) error {
+	pos := EpisodePosition{
+		UserID:           userID,
+		PodcastEpisodeID: episodeID,
+		PositionSeconds:  position,
+		UpdatedAt:        time.Now(),
+	}
+	return db.Save(&pos).Error
+}
+
+// GetEpisodePosition returns a user's saved resume position for an episode,
+// or 0 if none has been recorded yet.
+func GetEpisodePosition(db *gorm.DB, userID string, episodeID uint) (float64, error) {
+	var pos EpisodePosition
+	err := db.Where("user_id = ? AND podcast_episode_id = ?", userID, episodeID).First(&pos).Error
+	if err != nil {
+		return 0, nil
+	}
+	return pos.PositionSeconds, nil
+}
+
+// --- REST routes -------------------------------------------------------
+
+// RegisterPodcastRoutes mounts the podcast subscription/download/resume
+// surface under /api/podcasts.
+func RegisterPodcastRoutes(app *fiber.App, mgr *PodcastManager, db *gorm.DB) {
+	app.Post("/api/podcasts", subscribePodcastHandler(mgr))
+	app.Get("/api/podcasts", listPodcastsHandler(db))
+	app.Delete("/api/podcasts/:id", deletePodcastHandler(db))
+	app.Post("/api/podcasts/:id/refresh", refreshPodcastHandler(mgr))
+	app.Post("/api/podcasts/:id/episodes/:eid/download", downloadEpisodeHandler(mgr))
+	app.Get("/api/podcasts/:id/episodes/:eid/stream", streamEpisodeHandler(db))
+	app.Post("/api/podcasts/:id/episodes/:eid/position", saveEpisodePositionHandler(db))
+	app.Get("/api/podcasts/:id/episodes/:eid/position", getEpisodePositionHandler(db))
+}
+
+// subscribePodcastHandler handles POST /api/podcasts
+func subscribePodcastHandler(mgr *PodcastManager) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req struct {
+			FeedURL string `json:"feed_url"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Cannot parse JSON"})
+		}
+
+		podcast, err := mgr.Subscribe(req.FeedURL)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(podcast)
+	}
+}
+
+// listPodcastsHandler handles GET /api/podcasts
+func listPodcastsHandler(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var podcasts []Podcast
+		db.Preload("Episodes").Find(&podcasts)
+		return c.JSON(podcasts)
+	}
+}
+
+// refreshPodcastHandler handles POST /api/podcasts/:id/refresh
+func refreshPodcastHandler(mgr *PodcastManager) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var id uint
+		if _, err := fmt.Sscanf(c.Params("id"), "%d", &id); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid podcast id"})
+		}
+
+		added, err := mgr.Refresh(id)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(fiber.Map{"new_episodes": added})
+	}
+}
+
+// downloadEpisodeHandler handles POST /api/podcasts/:id/episodes/:eid/download
+func downloadEpisodeHandler(mgr *PodcastManager) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var episodeID uint
+		if _, err := fmt.Sscanf(c.Params("eid"), "%d", &episodeID); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid episode id"})
+		}
+
+		go func() {
+			if err := mgr.DownloadEpisode(episodeID); err != nil {
+				log.Printf("podcast: download failed for episode %d: %v", episodeID, err)
+			}
+		}()
+
+		return c.JSON(fiber.Map{"message": "Download started"})
+	}
+}
+
+// episodeIDFromStreamPath pulls :eid out of
+// "/api/podcasts/:id/episodes/:eid/stream", since adaptor.HTTPHandlerFunc
+// hands the handler a plain net/http request with no Fiber route params.
+func episodeIDFromStreamPath(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) < 2 {
+		return ""
+	}
+	return segments[len(segments)-2]
+}
+
+// streamEpisodeHandler handles GET /api/podcasts/:id/episodes/:eid/stream,
+// reusing Stream so downloaded episodes play through the same range-aware,
+// transcoding-capable pipeline as local library songs.
+func streamEpisodeHandler(db *gorm.DB) fiber.Handler {
+	return adaptor.HTTPHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		eid := episodeIDFromStreamPath(r.URL.Path)
+
+		var episode PodcastEpisode
+		if err := db.First(&episode, eid).Error; err != nil || !episode.Downloaded {
+			http.Error(w, "Episode not found or not downloaded yet", http.StatusNotFound)
+			return
+		}
+
+		relFile := episode.LocalFile
+		if rel, err := filepath.Rel("podcasts", episode.LocalFile); err == nil {
+			relFile = rel
+		}
+
+		streamSong := StreamSong{ID: episode.ID, File: relFile, SourceRoot: "podcasts"}
+		opts := StreamOptions{Format: r.URL.Query().Get("format")}
+		if br := r.URL.Query().Get("bitrate"); br != "" {
+			opts.Bitrate, _ = strconv.Atoi(br)
+		}
+
+		if err := Stream(w, r, streamSong, opts); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// saveEpisodePositionHandler handles POST /api/podcasts/:id/episodes/:eid/position
+func saveEpisodePositionHandler(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var episodeID uint
+		if _, err := fmt.Sscanf(c.Params("eid"), "%d", &episodeID); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid episode id"})
+		}
+
+		var req struct {
+			UserID   string  `json:"user_id"`
+			Position float64 `json:"position_seconds"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Cannot parse JSON"})
+		}
+
+		if err := SaveEpisodePosition(db, req.UserID, episodeID, req.Position); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to save position"})
+		}
+
+		return c.JSON(fiber.Map{"message": "Position saved"})
+	}
+}
+
+// getEpisodePositionHandler handles GET /api/podcasts/:id/episodes/:eid/position?user_id=...
+func getEpisodePositionHandler(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var episodeID uint
+		if _, err := fmt.Sscanf(c.Params("eid"), "%d", &episodeID); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid episode id"})
+		}
+
+		position, err := GetEpisodePosition(db, c.Query("user_id"), episodeID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to load position"})
+		}
+
+		return c.JSON(fiber.Map{"position_seconds": position})
+	}
+}
+
+// deletePodcastHandler handles DELETE /api/podcasts/:id
+func deletePodcastHandler(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+
+		if err := db.Where("podcast_id = ?", id).Delete(&PodcastEpisode{}).Error; err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to delete episodes"})
+		}
+
+		result := db.Delete(&Podcast{}, id)
+		if result.Error != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to delete podcast"})
+		}
+		if result.RowsAffected == 0 {
+			return c.Status(404).JSON(fiber.Map{"error": "Podcast not found"})
+		}
+
+		return c.SendString("Podcast deleted")
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestParseITunesDuration(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want int
+	}{
+		{name: "plain seconds", raw: "1800", want: 1800},
+		{name: "MM:SS", raw: "29:30", want: 1770},
+		{name: "HH:MM:SS", raw: "1:02:03", want: 3723},
+		{name: "empty string", raw: "", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseITunesDuration(tt.raw); got != tt.want {
+				t.Errorf("parseITunesDuration(%q) = %d, want %d", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
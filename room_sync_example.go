@@ -0,0 +1,252 @@
+// room_sync_example.go - Example implementation of synchronized group playback
+// This is a conceptual example showing how to turn the Hub from a dumb
+// broadcast relay into a server-authoritative "group session" with NTP-style
+// clock alignment between devices.
+
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+// RoomMessageType extends MessageType with the room-session protocol.
+type RoomMessageType string
+
+const (
+	PingMessage         RoomMessageType = "ping"
+	PongMessage         RoomMessageType = "pong"
+	JoinRoomMessage     RoomMessageType = "join_room"
+	LeaveRoomMessage    RoomMessageType = "leave_room"
+	TransferHostMessage RoomMessageType = "transfer_host"
+	ResyncMessage       RoomMessageType = "resync"
+	RoomStateMessage    RoomMessageType = "room_state"
+)
+
+// RoomMessage is the envelope for all room-protocol WebSocket traffic.
+type RoomMessage struct {
+	Type   RoomMessageType `json:"type"`
+	RoomID string          `json:"room_id,omitempty"`
+	UserID string          `json:"user_id,omitempty"`
+
+	// ping/pong clock alignment, per the NTP formula:
+	// offset = ((t1-t0)+(t2-t3))/2, rtt = (t3-t0)-(t2-t1)
+	T0 int64 `json:"t0,omitempty"`
+	T1 int64 `json:"t1,omitempty"`
+	T2 int64 `json:"t2,omitempty"`
+	T3 int64 `json:"t3,omitempty"`
+
+	State *RoomState `json:"state,omitempty"`
+}
+
+// RoomState is the canonical, server-authoritative playback state of a room.
+type RoomState struct {
+	SongID               string  `json:"song_id"`
+	StartedAtMonotonicMs int64   `json:"started_at_monotonic_ms"`
+	PausedAt             float64 `json:"paused_at,omitempty"` // seconds into the track, 0 if playing
+	PlaybackRate         float64 `json:"playback_rate"`
+	HostUserID           string  `json:"host_user_id"`
+}
+
+// Room owns the canonical playback state for a group of listeners and
+// guards every mutation with its own mutex so concurrent join/play/seek
+// messages can't interleave into an inconsistent state.
+type Room struct {
+	ID      string
+	Hub     *Hub
+	mu      sync.Mutex
+	state   RoomState
+	Members map[string]*Client // userID -> client
+}
+
+// RoomRegistry tracks all active rooms, keyed by room ID.
+type RoomRegistry struct {
+	mu    sync.Mutex
+	rooms map[string]*Room
+}
+
+// NewRoomRegistry creates an empty room registry.
+func NewRoomRegistry() *RoomRegistry {
+	return &RoomRegistry{rooms: make(map[string]*Room)}
+}
+
+// GetOrCreate returns the room with the given ID, creating it if needed.
+func (reg *RoomRegistry) GetOrCreate(id string, hub *Hub) *Room {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if room, ok := reg.rooms[id]; ok {
+		return room
+	}
+
+	room := &Room{
+		ID:      id,
+		Hub:     hub,
+		Members: make(map[string]*Client),
+	}
+	reg.rooms[id] = room
+	return room
+}
+
+// Join adds a client to the room. The first member becomes host.
+func (r *Room) Join(client *Client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.Members) == 0 {
+		r.state.HostUserID = client.UserID
+	}
+	r.Members[client.UserID] = client
+}
+
+// Leave removes a client from the room, transferring host to an arbitrary
+// remaining member if the host left.
+func (r *Room) Leave(userID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.Members, userID)
+
+	if r.state.HostUserID == userID {
+		for id := range r.Members {
+			r.state.HostUserID = id
+			break
+		}
+	}
+}
+
+// TransferHost reassigns the host to another member of the room.
+func (r *Room) TransferHost(newHostUserID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.Members[newHostUserID]; !ok {
+		return false
+	}
+	r.state.HostUserID = newHostUserID
+	return true
+}
+
+// Play sets the canonical state to "playing songID from position", recording
+// the server's monotonic start time so joining clients can compute how far
+// into the track playback already is.
+func (r *Room) Play(songID string, positionSeconds, rate float64) RoomState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	elapsedMs := positionSeconds * 1000
+	if rate > 0 {
+		elapsedMs /= rate // positionLocked scales elapsed time by rate
+	}
+	startedAt := nowMonotonicMs() - int64(elapsedMs)
+	r.state.SongID = songID
+	r.state.StartedAtMonotonicMs = startedAt
+	r.state.PausedAt = 0
+	r.state.PlaybackRate = rate
+	return r.state
+}
+
+// Pause freezes the canonical state at its current playback position.
+func (r *Room) Pause() RoomState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.state.PausedAt = r.positionLocked()
+	return r.state
+}
+
+// State returns a copy of the room's current canonical state.
+func (r *Room) State() RoomState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state
+}
+
+// positionLocked computes the current playback position in seconds. Caller
+// must hold r.mu.
+func (r *Room) positionLocked() float64 {
+	if r.state.PausedAt > 0 {
+		return r.state.PausedAt
+	}
+	elapsedMs := nowMonotonicMs() - r.state.StartedAtMonotonicMs
+	return float64(elapsedMs) / 1000 * r.state.PlaybackRate
+}
+
+// nowMonotonicMs returns the server's wall-clock time in Unix milliseconds
+// (not Go's monotonic clock reading), used as the server's t1/t2 in the NTP
+// handshake and as the basis for StartedAt.
+func nowMonotonicMs() int64 {
+	return time.Now().UnixMilli()
+}
+
+// ClockOffset is the result of one ping/pong round trip: how far the
+// client's clock is from the server's, and the measured round-trip time.
+type ClockOffset struct {
+	OffsetMs int64
+	RTTMs    int64
+}
+
+// ComputeClockOffset implements the NTP offset/RTT formulas from t0 (client
+// send), t1 (server receive), t2 (server send), t3 (client receive).
+func ComputeClockOffset(t0, t1, t2, t3 int64) ClockOffset {
+	return ClockOffset{
+		OffsetMs: ((t1 - t0) + (t2 - t3)) / 2,
+		RTTMs:    (t3 - t0) - (t2 - t1),
+	}
+}
+
+// HandleRoomMessage processes one room-protocol message from a client and
+// returns the response to send back (if any) plus an optional broadcast to
+// every member of the room, the sender included.
+func HandleRoomMessage(reg *RoomRegistry, hub *Hub, client *Client, raw []byte) (response []byte, broadcast []byte) {
+	receivedAtMs := nowMonotonicMs() // captured before any processing, for t1
+
+	var msg RoomMessage
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		log.Printf("room: error unmarshaling message: %v", err)
+		return nil, nil
+	}
+
+	switch msg.Type {
+	case PingMessage:
+		pong := RoomMessage{
+			Type: PongMessage,
+			T0:   msg.T0,
+			T1:   receivedAtMs,
+			T2:   nowMonotonicMs(), // captured just before sending, for the client's t2
+		}
+		data, _ := json.Marshal(pong)
+		return data, nil
+
+	case JoinRoomMessage:
+		room := reg.GetOrCreate(msg.RoomID, hub)
+		room.Join(client)
+		state := room.State()
+		data, _ := json.Marshal(RoomMessage{Type: RoomStateMessage, RoomID: msg.RoomID, State: &state})
+		return data, nil
+
+	case LeaveRoomMessage:
+		if room := reg.GetOrCreate(msg.RoomID, hub); room != nil {
+			room.Leave(client.UserID)
+		}
+		return nil, nil
+
+	case TransferHostMessage:
+		room := reg.GetOrCreate(msg.RoomID, hub)
+		room.TransferHost(msg.UserID)
+		state := room.State()
+		data, _ := json.Marshal(RoomMessage{Type: RoomStateMessage, RoomID: msg.RoomID, State: &state})
+		return nil, data
+
+	case ResyncMessage:
+		room := reg.GetOrCreate(msg.RoomID, hub)
+		state := room.State()
+		data, _ := json.Marshal(RoomMessage{Type: RoomStateMessage, RoomID: msg.RoomID, State: &state})
+		return data, nil
+
+	default:
+		return nil, nil
+	}
+}
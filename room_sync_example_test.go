@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestComputeClockOffset(t *testing.T) {
+	tests := []struct {
+		name           string
+		t0, t1, t2, t3 int64
+		wantOffset     int64
+		wantRTT        int64
+	}{
+		{
+			name: "no drift, symmetric latency",
+			t0:   1000, t1: 1010, t2: 1010, t3: 1020,
+			wantOffset: 0,
+			wantRTT:    20,
+		},
+		{
+			name: "client clock ahead of server",
+			t0:   2000, t1: 1900, t2: 1900, t3: 2020,
+			wantOffset: -110,
+			wantRTT:    20,
+		},
+		{
+			name: "client clock behind server",
+			t0:   1000, t1: 1150, t2: 1160, t3: 1100,
+			wantOffset: 105,
+			wantRTT:    90,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ComputeClockOffset(tt.t0, tt.t1, tt.t2, tt.t3)
+			if got.OffsetMs != tt.wantOffset || got.RTTMs != tt.wantRTT {
+				t.Errorf("ComputeClockOffset(%d,%d,%d,%d) = %+v, want offset=%d rtt=%d",
+					tt.t0, tt.t1, tt.t2, tt.t3, got, tt.wantOffset, tt.wantRTT)
+			}
+		})
+	}
+}
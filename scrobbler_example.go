@@ -0,0 +1,495 @@
+// scrobbler_example.go - Example implementation of Last.fm/ListenBrainz scrobbling
+// This is a conceptual example showing how to add "now playing" and scrobble
+// submission hooked into the existing play/pause WebSocket messages.
+
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// UserCredential stores a user's per-service scrobbling session.
+type UserCredential struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UserID     string    `gorm:"index" json:"user_id"`
+	Service    string    `json:"service"` // "lastfm" or "listenbrainz"
+	SessionKey string    `json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Track is the minimal info a Scrobbler needs about what's playing.
+type Track struct {
+	Artist   string
+	Title    string
+	Album    string
+	Duration time.Duration
+}
+
+// Scrobbler is implemented by each scrobbling backend.
+type Scrobbler interface {
+	// Name identifies the backend, matching the UserCredential.Service and
+	// QueuedScrobbleRow.Service values ("lastfm", "listenbrainz").
+	Name() string
+	// NowPlaying tells the service what's currently playing, with no
+	// lasting effect on listening history.
+	NowPlaying(cred UserCredential, track Track) error
+	// Submit records a completed listen, timestamped by when it started.
+	Submit(cred UserCredential, track Track, startedAt time.Time) error
+}
+
+// --- Last.fm -----------------------------------------------------------
+
+// LastFMScrobbler implements Scrobbler against the Last.fm API, which
+// requires every request to be signed with an API secret.
+type LastFMScrobbler struct {
+	APIKey    string
+	APISecret string
+	Client    *http.Client
+}
+
+const lastFMAPIRoot = "https://ws.audioscrobbler.com/2.0/"
+
+// Name implements Scrobbler.
+func (s *LastFMScrobbler) Name() string { return "lastfm" }
+
+// GetToken implements the first step of the Last.fm desktop auth flow.
+func (s *LastFMScrobbler) GetToken() (string, error) {
+	resp, err := s.call(map[string]string{"method": "auth.getToken"})
+	if err != nil {
+		return "", err
+	}
+	var out struct {
+		Token string `xml:"token" json:"token"`
+	}
+	if err := json.Unmarshal(resp, &out); err != nil {
+		return "", err
+	}
+	return out.Token, nil
+}
+
+// GetSession exchanges an authorized token for a long-lived session key.
+func (s *LastFMScrobbler) GetSession(token string) (string, error) {
+	resp, err := s.call(map[string]string{"method": "auth.getSession", "token": token})
+	if err != nil {
+		return "", err
+	}
+	var out struct {
+		Session struct {
+			Key string `json:"key"`
+		} `json:"session"`
+	}
+	if err := json.Unmarshal(resp, &out); err != nil {
+		return "", err
+	}
+	return out.Session.Key, nil
+}
+
+func (s *LastFMScrobbler) NowPlaying(cred UserCredential, track Track) error {
+	_, err := s.call(map[string]string{
+		"method": "track.updateNowPlaying",
+		"artist": track.Artist,
+		"track":  track.Title,
+		"album":  track.Album,
+		"sk":     cred.SessionKey,
+	})
+	return err
+}
+
+func (s *LastFMScrobbler) Submit(cred UserCredential, track Track, startedAt time.Time) error {
+	_, err := s.call(map[string]string{
+		"method":    "track.scrobble",
+		"artist":    track.Artist,
+		"track":     track.Title,
+		"album":     track.Album,
+		"timestamp": strconv.FormatInt(startedAt.Unix(), 10),
+		"sk":        cred.SessionKey,
+	})
+	return err
+}
+
+// call signs params per the Last.fm API signature scheme (md5 of each key
+// followed directly by its value, in sorted key order, then the shared
+// secret) and issues a POST.
+func (s *LastFMScrobbler) call(params map[string]string) ([]byte, error) {
+	params["api_key"] = s.APIKey
+	params["format"] = "json"
+	params["api_sig"] = s.sign(params)
+
+	form := url.Values{}
+	for k, v := range params {
+		form.Set(k, v)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.PostForm(lastFMAPIRoot, form)
+	if err != nil {
+		return nil, fmt.Errorf("lastfm request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("lastfm error: %s", body)
+	}
+	return body, nil
+}
+
+func (s *LastFMScrobbler) sign(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if k == "format" {
+			continue // excluded from the signature per the Last.fm spec
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	for _, k := range keys {
+		buf.WriteString(k)
+		buf.WriteString(params[k])
+	}
+	buf.WriteString(s.APISecret)
+
+	return fmt.Sprintf("%x", md5.Sum(buf.Bytes()))
+}
+
+// --- ListenBrainz --------------------------------------------------------
+
+// ListenBrainzScrobbler implements Scrobbler against the ListenBrainz
+// /1/submit-listens endpoint, authenticated with a per-user token.
+type ListenBrainzScrobbler struct {
+	Client *http.Client
+}
+
+const listenBrainzSubmitURL = "https://api.listenbrainz.org/1/submit-listens"
+
+// Name implements Scrobbler.
+func (s *ListenBrainzScrobbler) Name() string { return "listenbrainz" }
+
+type listenBrainzPayload struct {
+	ListenType string `json:"listen_type"`
+	Payload    []struct {
+		ListenedAt    int64 `json:"listened_at,omitempty"`
+		TrackMetadata struct {
+			ArtistName  string `json:"artist_name"`
+			TrackName   string `json:"track_name"`
+			ReleaseName string `json:"release_name,omitempty"`
+		} `json:"track_metadata"`
+	} `json:"payload"`
+}
+
+func (s *ListenBrainzScrobbler) NowPlaying(cred UserCredential, track Track) error {
+	return s.submit(cred, "playing_now", track, time.Time{})
+}
+
+func (s *ListenBrainzScrobbler) Submit(cred UserCredential, track Track, startedAt time.Time) error {
+	return s.submit(cred, "single", track, startedAt)
+}
+
+func (s *ListenBrainzScrobbler) submit(cred UserCredential, listenType string, track Track, startedAt time.Time) error {
+	payload := listenBrainzPayload{ListenType: listenType}
+	entry := struct {
+		ListenedAt    int64 `json:"listened_at,omitempty"`
+		TrackMetadata struct {
+			ArtistName  string `json:"artist_name"`
+			TrackName   string `json:"track_name"`
+			ReleaseName string `json:"release_name,omitempty"`
+		} `json:"track_metadata"`
+	}{}
+	entry.TrackMetadata.ArtistName = track.Artist
+	entry.TrackMetadata.TrackName = track.Title
+	entry.TrackMetadata.ReleaseName = track.Album
+	if !startedAt.IsZero() {
+		entry.ListenedAt = startedAt.Unix()
+	}
+	payload.Payload = append(payload.Payload, entry)
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, listenBrainzSubmitURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Token "+cred.SessionKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("listenbrainz request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("listenbrainz error (%d): %s", resp.StatusCode, respBody)
+	}
+	return nil
+}
+
+// --- Play tracking / the 50% or 4-minute rule ----------------------------
+
+// PlaybackTracker watches play/pause events across every connected user's
+// current track and submits exactly one scrobble per user once Last.fm's
+// "played past 50% or 4 minutes, whichever is first" threshold is crossed.
+type PlaybackTracker struct {
+	DB         *gorm.DB
+	Scrobblers []Scrobbler
+	Queue      *ScrobbleQueue
+
+	mu    sync.Mutex
+	state map[string]*trackState // userID -> that user's in-flight track
+}
+
+// trackState is one user's currently-tracked track, kept separate per user
+// so one listener's play/pause events can't clobber another's.
+type trackState struct {
+	track     Track
+	startedAt time.Time
+	submitted bool
+}
+
+// OnPlay is called from the PlayMessage WebSocket handler.
+func (t *PlaybackTracker) OnPlay(userID string, track Track, position time.Duration) {
+	t.mu.Lock()
+	if t.state == nil {
+		t.state = make(map[string]*trackState)
+	}
+	st, ok := t.state[userID]
+	if !ok || st.track != track {
+		st = &trackState{track: track, startedAt: time.Now().Add(-position)}
+		t.state[userID] = st
+	}
+	t.mu.Unlock()
+
+	for _, cred := range t.credentialsFor(userID) {
+		for _, s := range t.Scrobblers {
+			if s.Name() != cred.Service {
+				continue
+			}
+			if err := s.NowPlaying(cred, track); err != nil {
+				log.Printf("scrobbler: now playing failed: %v", err)
+			}
+		}
+	}
+}
+
+// OnPause checks the scrobble threshold
+// for userID's current track and submits once, queuing on failure so it
+// survives restarts.
+func (t *PlaybackTracker) OnPause(userID string, position time.Duration) {
+	t.mu.Lock()
+	st, ok := t.state[userID]
+	if !ok || st.submitted || st.track.Duration == 0 {
+		t.mu.Unlock()
+		return
+	}
+
+	threshold := st.track.Duration / 2
+	if fourMin := 4 * time.Minute; fourMin < threshold {
+		threshold = fourMin
+	}
+
+	if position < threshold {
+		t.mu.Unlock()
+		return
+	}
+
+	st.submitted = true
+	track, startedAt := st.track, st.startedAt
+	t.mu.Unlock()
+
+	for _, cred := range t.credentialsFor(userID) {
+		for _, s := range t.Scrobblers {
+			if s.Name() != cred.Service {
+				continue
+			}
+			if err := s.Submit(cred, track, startedAt); err != nil {
+				log.Printf("scrobbler: submit failed, queuing for retry: %v", err)
+				t.Queue.Enqueue(QueuedScrobble{Credential: cred, Track: track, StartedAt: startedAt})
+			}
+		}
+	}
+}
+
+func (t *PlaybackTracker) credentialsFor(userID string) []UserCredential {
+	var creds []UserCredential
+	t.DB.Where("user_id = ?", userID).Find(&creds)
+	return creds
+}
+
+// --- Retry queue -----------------------------------------------------------
+
+// QueuedScrobble is a scrobble submission waiting to be retried.
+type QueuedScrobble struct {
+	Credential UserCredential
+	Track      Track
+	StartedAt  time.Time
+	Attempts   int
+}
+
+// ScrobbleQueue persists failed scrobbles to disk (via GORM) and retries
+// them with exponential backoff so submissions survive restarts and
+// network outages.
+type ScrobbleQueue struct {
+	DB         *gorm.DB
+	Scrobblers []Scrobbler
+}
+
+// QueuedScrobbleRow is the on-disk representation of a QueuedScrobble. It
+// carries the credential's SessionKey so a retry can actually authenticate
+// instead of submitting with an empty key.
+type QueuedScrobbleRow struct {
+	ID         uint      `gorm:"primaryKey"`
+	UserID     string    `json:"user_id"`
+	Service    string    `json:"service"`
+	SessionKey string    `json:"-"`
+	Artist     string    `json:"artist"`
+	Title      string    `json:"title"`
+	Album      string    `json:"album"`
+	StartedAt  time.Time `json:"started_at"`
+	Attempts   int       `json:"attempts"`
+	NextRetry  time.Time `json:"next_retry"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Enqueue persists a failed scrobble so it can be retried later.
+func (q *ScrobbleQueue) Enqueue(item QueuedScrobble) {
+	row := QueuedScrobbleRow{
+		UserID:     item.Credential.UserID,
+		Service:    item.Credential.Service,
+		SessionKey: item.Credential.SessionKey,
+		Artist:     item.Track.Artist,
+		Title:      item.Track.Title,
+		Album:      item.Track.Album,
+		StartedAt:  item.StartedAt,
+		Attempts:   item.Attempts,
+		NextRetry:  time.Now(),
+	}
+	if err := q.DB.Create(&row).Error; err != nil {
+		log.Printf("scrobble queue: failed to persist: %v", err)
+	}
+}
+
+// RetryDue retries every queued scrobble whose backoff has elapsed, routing
+// each one only to the Scrobbler matching its original Service so a failed
+// Last.fm scrobble isn't resubmitted to ListenBrainz (or vice versa).
+func (q *ScrobbleQueue) RetryDue() {
+	var rows []QueuedScrobbleRow
+	q.DB.Where("next_retry <= ?", time.Now()).Find(&rows)
+
+	for _, row := range rows {
+		cred := UserCredential{UserID: row.UserID, Service: row.Service, SessionKey: row.SessionKey}
+		track := Track{Artist: row.Artist, Title: row.Title, Album: row.Album}
+
+		scrobbler := q.scrobblerFor(row.Service)
+		if scrobbler == nil {
+			log.Printf("scrobble queue: no scrobbler registered for service %q", row.Service)
+			continue
+		}
+
+		if err := scrobbler.Submit(cred, track, row.StartedAt); err != nil {
+			row.Attempts++
+			backoff := time.Duration(1<<row.Attempts) * time.Second
+			row.NextRetry = time.Now().Add(backoff)
+			q.DB.Save(&row)
+			continue
+		}
+
+		q.DB.Delete(&row)
+	}
+}
+
+func (q *ScrobbleQueue) scrobblerFor(service string) Scrobbler {
+	for _, s := range q.Scrobblers {
+		if s.Name() == service {
+			return s
+		}
+	}
+	return nil
+}
+
+// scrobblerBackends constructs the configured Scrobbler backends. API
+// credentials are read from the environment so nothing secret is compiled
+// in; a backend with an empty key still registers so the retry queue can
+// route queued rows by service name consistently.
+func scrobblerBackends() []Scrobbler {
+	return []Scrobbler{
+		&LastFMScrobbler{APIKey: os.Getenv("LASTFM_API_KEY"), APISecret: os.Getenv("LASTFM_API_SECRET")},
+		&ListenBrainzScrobbler{},
+	}
+}
+
+// retryScrobblesPeriodically drains the on-disk retry queue on a fixed
+// interval so scrobbles that failed, or were queued across a restart,
+// eventually get submitted.
+func retryScrobblesPeriodically(queue *ScrobbleQueue) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		queue.RetryDue()
+	}
+}
+
+// --- REST/WebSocket wiring -------------------------------------------------
+
+// listensHandler handles POST /api/listens, allowing clients to report a
+// listen directly (e.g. for offline playback) instead of relying solely on
+// the WebSocket play/pause stream.
+func listensHandler(tracker *PlaybackTracker) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req struct {
+			UserID   string  `json:"user_id"`
+			Artist   string  `json:"artist"`
+			Title    string  `json:"title"`
+			Album    string  `json:"album"`
+			Position float64 `json:"position_seconds"`
+			Duration float64 `json:"duration_seconds"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Cannot parse JSON"})
+		}
+
+		track := Track{
+			Artist:   req.Artist,
+			Title:    req.Title,
+			Album:    req.Album,
+			Duration: time.Duration(req.Duration) * time.Second,
+		}
+
+		tracker.OnPlay(req.UserID, track, time.Duration(req.Position)*time.Second)
+		tracker.OnPause(req.UserID, time.Duration(req.Position)*time.Second)
+
+		return c.JSON(fiber.Map{"message": "Listen recorded"})
+	}
+}
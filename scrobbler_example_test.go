@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestLastFMScrobblerSign(t *testing.T) {
+	s := &LastFMScrobbler{APIKey: "key123", APISecret: "shh"}
+
+	params := map[string]string{
+		"method":  "track.scrobble",
+		"artist":  "Foo",
+		"track":   "Bar",
+		"sk":      "sess456",
+		"api_key": "key123",
+		"format":  "json", // must be excluded from the signature
+	}
+
+	want := "efc79e5b3d6bdde9e97627550b00450a"
+	if got := s.sign(params); got != want {
+		t.Errorf("sign(%+v) = %q, want %q", params, got, want)
+	}
+}
+
+func TestLastFMScrobblerSignIgnoresFormat(t *testing.T) {
+	s := &LastFMScrobbler{APIKey: "key123", APISecret: "shh"}
+
+	withFormat := map[string]string{
+		"method":  "track.scrobble",
+		"artist":  "Foo",
+		"track":   "Bar",
+		"sk":      "sess456",
+		"api_key": "key123",
+		"format":  "json",
+	}
+	withoutFormat := map[string]string{
+		"method":  "track.scrobble",
+		"artist":  "Foo",
+		"track":   "Bar",
+		"sk":      "sess456",
+		"api_key": "key123",
+	}
+
+	if s.sign(withFormat) != s.sign(withoutFormat) {
+		t.Error("sign output changed depending on presence of the format param")
+	}
+}
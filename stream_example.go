@@ -0,0 +1,262 @@
+// stream_example.go - Example implementation of the streaming subsystem
+// This is a conceptual example showing how to replace plain http.ServeFile
+// streaming with range-aware, on-the-fly transcoding streaming shared by
+// both the legacy net/http handler and the Fiber router.
+
+package main
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+)
+
+// StreamSong is the minimal view of a Song the stream package needs.
+type StreamSong struct {
+	ID   uint
+	File string // path relative to SourceRoot
+	// SourceRoot is the directory File is resolved against; defaults to
+	// "songs" so existing callers (local library songs) are unaffected.
+	// Podcast episodes pass "podcasts" to reuse this same entry point.
+	SourceRoot string
+	Duration   int // seconds; the byte->time mapping for transcoded ranges uses bitrate only and does not read it
+}
+
+// StreamOptions selects an optional transcode target.
+type StreamOptions struct {
+	Format  string // "" means serve the original file as-is
+	Bitrate int    // kbps, defaults to 128 when a format is given
+	IcyMeta bool   // client sent "Icy-MetaData: 1"
+}
+
+const transcodeCacheDir = "cache/transcodes"
+
+// mimeTypeFor returns the Content-Type for a given (possibly transcoded) format.
+func mimeTypeFor(format string) string {
+	switch strings.ToLower(format) {
+	case "mp3":
+		return "audio/mpeg"
+	case "opus":
+		return "audio/ogg; codecs=opus"
+	case "flac":
+		return "audio/flac"
+	case "wav":
+		return "audio/wav"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// Stream serves song to w/r, honoring Range requests and transcoding on the
+// fly when opts.Format is set. It's the single entry point reused by both
+// the legacy net/http handler and the Fiber router.
+func Stream(w http.ResponseWriter, r *http.Request, song StreamSong, opts StreamOptions) error {
+	root := song.SourceRoot
+	if root == "" {
+		root = "songs"
+	}
+	sourcePath := filepath.Join(root, song.File)
+
+	if opts.IcyMeta {
+		w.Header().Set("icy-metaint", "0")
+		w.Header().Set("icy-name", song.File)
+	}
+
+	if opts.Format == "" {
+		return serveOriginal(w, r, sourcePath)
+	}
+
+	return serveTranscoded(w, r, song, sourcePath, opts)
+}
+
+// serveOriginal streams the file on disk unmodified; http.ServeFile already
+// understands Range and Accept-Ranges for this case.
+func serveOriginal(w http.ResponseWriter, r *http.Request, path string) error {
+	if _, err := os.Stat(path); err != nil {
+		http.NotFound(w, r)
+		return err
+	}
+	http.ServeFile(w, r, path)
+	return nil
+}
+
+// serveTranscoded serves a cached transcode if one exists, otherwise
+// transcodes via ffmpeg, caching the result keyed by (songID, format, bitrate).
+func serveTranscoded(w http.ResponseWriter, r *http.Request, song StreamSong, sourcePath string, opts StreamOptions) error {
+	bitrate := opts.Bitrate
+	if bitrate == 0 {
+		bitrate = 128
+	}
+
+	cachePath := transcodeCachePath(song.ID, opts.Format, bitrate)
+
+	if _, err := os.Stat(cachePath); err == nil {
+		w.Header().Set("Content-Type", mimeTypeFor(opts.Format))
+		http.ServeFile(w, r, cachePath)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return err
+	}
+
+	if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
+		return streamTranscodeRange(w, r, song, sourcePath, cachePath, opts, bitrate)
+	}
+
+	return transcodeToCacheAndServe(w, r, sourcePath, cachePath, opts, bitrate)
+}
+
+// transcodeToCacheAndServe runs ffmpeg once, writing the output to the cache
+// file while also streaming it to the client (length unknown up front, so
+// we fall back to chunked transfer).
+func transcodeToCacheAndServe(w http.ResponseWriter, r *http.Request, sourcePath, cachePath string, opts StreamOptions, bitrate int) error {
+	w.Header().Set("Content-Type", mimeTypeFor(opts.Format))
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.WriteHeader(http.StatusOK)
+
+	cacheFile, err := os.Create(cachePath)
+	if err != nil {
+		return err
+	}
+	defer cacheFile.Close()
+
+	cmd := ffmpegCommand(sourcePath, opts.Format, bitrate, 0)
+	cmd.Stdout = io.MultiWriter(w, cacheFile)
+	cmd.Stderr = nil
+
+	if err := cmd.Run(); err != nil {
+		os.Remove(cachePath) // don't cache a partial/failed transcode
+		return fmt.Errorf("ffmpeg transcode failed: %w", err)
+	}
+	return nil
+}
+
+// streamTranscodeRange pre-seeks the ffmpeg decoder to the requested byte
+// offset (mapped to an approximate timestamp from the output bitrate, assumed
+// constant) so scrubbing doesn't require decoding from zero.
+func streamTranscodeRange(w http.ResponseWriter, r *http.Request, song StreamSong, sourcePath, cachePath string, opts StreamOptions, bitrate int) error {
+	rangeHeader := r.Header.Get("Range")
+	startByte, err := parseRangeStart(rangeHeader)
+	if err != nil {
+		http.Error(w, "Invalid Range header", http.StatusRequestedRangeNotSatisfiable)
+		return err
+	}
+
+	seekSeconds := byteOffsetToSeconds(startByte, bitrate)
+
+	w.Header().Set("Content-Type", mimeTypeFor(opts.Format))
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-*/*", startByte))
+	w.WriteHeader(http.StatusPartialContent)
+
+	cmd := ffmpegCommand(sourcePath, opts.Format, bitrate, seekSeconds)
+	cmd.Stdout = w
+	return cmd.Run()
+}
+
+// ffmpegCommand builds the ffmpeg invocation used for transcoding. seekSeconds
+// of 0 means start from the beginning.
+func ffmpegCommand(sourcePath, format string, bitrate int, seekSeconds float64) *exec.Cmd {
+	args := []string{}
+	if seekSeconds > 0 {
+		args = append(args, "-ss", strconv.FormatFloat(seekSeconds, 'f', 2, 64))
+	}
+	args = append(args, "-i", sourcePath, "-b:a", fmt.Sprintf("%dk", bitrate), "-f", ffmpegFormat(format), "pipe:1")
+	return exec.Command("ffmpeg", args...)
+}
+
+func ffmpegFormat(format string) string {
+	switch strings.ToLower(format) {
+	case "opus":
+		return "opus"
+	default:
+		return "mp3"
+	}
+}
+
+// byteOffsetToSeconds maps a byte offset in the transcoded output to an
+// approximate timestamp, assuming a constant output bitrate.
+func byteOffsetToSeconds(byteOffset int64, bitrateKbps int) float64 {
+	bytesPerSecond := float64(bitrateKbps) * 1000 / 8
+	if bytesPerSecond == 0 {
+		return 0
+	}
+	return float64(byteOffset) / bytesPerSecond
+}
+
+func parseRangeStart(header string) (int64, error) {
+	spec := strings.TrimPrefix(header, "bytes=")
+	parts := strings.SplitN(spec, "-", 2)
+	return strconv.ParseInt(parts[0], 10, 64)
+}
+
+func transcodeCachePath(songID uint, format string, bitrate int) string {
+	key := fmt.Sprintf("%d-%s-%d", songID, format, bitrate)
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(transcodeCacheDir, fmt.Sprintf("%x.%s", sum, format))
+}
+
+// streamSongHandlerV2 is the net/http entry point, replacing the original
+// streamSongHandler's direct call to http.ServeFile.
+func streamSongHandlerV2(lookupSong func(file string) (StreamSong, bool)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filePath := strings.TrimPrefix(r.URL.Path, "/song/")
+		if strings.Contains(filePath, "..") {
+			http.Error(w, "Invalid file path", http.StatusBadRequest)
+			return
+		}
+
+		song, ok := lookupSong(filePath)
+		if !ok {
+			http.Error(w, "File not found", http.StatusNotFound)
+			return
+		}
+
+		opts := StreamOptions{
+			Format:  r.URL.Query().Get("format"),
+			IcyMeta: r.Header.Get("Icy-MetaData") == "1",
+		}
+		if bitrate := r.URL.Query().Get("bitrate"); bitrate != "" {
+			opts.Bitrate, _ = strconv.Atoi(bitrate)
+		}
+
+		if err := Stream(w, r, song, opts); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// streamSongFiberHandler adapts Stream for the Fiber router.
+func streamSongFiberHandler(lookupSong func(id string) (StreamSong, bool)) fiber.Handler {
+	return adaptor.HTTPHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/api/stream/")
+
+		song, ok := lookupSong(id)
+		if !ok {
+			http.Error(w, "Song not found", http.StatusNotFound)
+			return
+		}
+
+		opts := StreamOptions{
+			Format:  r.URL.Query().Get("format"),
+			IcyMeta: r.Header.Get("Icy-MetaData") == "1",
+		}
+		if bitrate := r.URL.Query().Get("bitrate"); bitrate != "" {
+			opts.Bitrate, _ = strconv.Atoi(bitrate)
+		}
+
+		if err := Stream(w, r, song, opts); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
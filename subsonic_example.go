@@ -0,0 +1,491 @@
+// subsonic_example.go - Example implementation of a Subsonic-compatible API
+// This is a conceptual example showing how to expose the library over the
+// Subsonic REST API so existing clients (DSub, Symfonium, Navidrome apps)
+// work against this server out of the box.
+
+package main
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"gorm.io/gorm"
+)
+
+const subsonicAPIVersion = "1.16.1"
+
+// subsonicResponse is the common envelope every Subsonic endpoint returns,
+// wrapped in either "subsonic-response" (XML) or the same key (JSON).
+type subsonicResponse struct {
+	XMLName xml.Name `json:"-" xml:"subsonic-response"`
+	Status  string   `json:"status" xml:"status,attr"`
+	Version string   `json:"version" xml:"version,attr"`
+
+	License       *subsonicLicense       `json:"license,omitempty" xml:"license,omitempty"`
+	MusicFolders  *subsonicMusicFolders  `json:"musicFolders,omitempty" xml:"musicFolders,omitempty"`
+	Indexes       *subsonicIndexes       `json:"indexes,omitempty" xml:"indexes,omitempty"`
+	Artists       *subsonicArtists       `json:"artists,omitempty" xml:"artists,omitempty"`
+	Album         *subsonicAlbum         `json:"album,omitempty" xml:"album,omitempty"`
+	Song          *subsonicSong          `json:"song,omitempty" xml:"song,omitempty"`
+	SearchResult3 *subsonicSearchResult3 `json:"searchResult3,omitempty" xml:"searchResult3,omitempty"`
+	Playlists     *subsonicPlaylists     `json:"playlists,omitempty" xml:"playlists,omitempty"`
+	Playlist      *subsonicPlaylist      `json:"playlist,omitempty" xml:"playlist,omitempty"`
+	Error         *subsonicError         `json:"error,omitempty" xml:"error,omitempty"`
+}
+
+type subsonicLicense struct {
+	Valid bool `json:"valid" xml:"valid,attr"`
+}
+
+type subsonicMusicFolders struct {
+	MusicFolder []subsonicMusicFolder `json:"musicFolder" xml:"musicFolder"`
+}
+
+type subsonicMusicFolder struct {
+	ID   int    `json:"id" xml:"id,attr"`
+	Name string `json:"name" xml:"name,attr"`
+}
+
+type subsonicIndexes struct {
+	Index []subsonicIndex `json:"index" xml:"index"`
+}
+
+type subsonicIndex struct {
+	Name   string           `json:"name" xml:"name,attr"`
+	Artist []subsonicArtist `json:"artist" xml:"artist"`
+}
+
+type subsonicArtists struct {
+	Index []subsonicIndex `json:"index" xml:"index"`
+}
+
+type subsonicArtist struct {
+	ID   string `json:"id" xml:"id,attr"`
+	Name string `json:"name" xml:"name,attr"`
+}
+
+type subsonicAlbum struct {
+	ID     string         `json:"id" xml:"id,attr"`
+	Name   string         `json:"name" xml:"name,attr"`
+	Artist string         `json:"artist" xml:"artist,attr"`
+	Song   []subsonicSong `json:"song" xml:"song"`
+}
+
+type subsonicSong struct {
+	ID       string `json:"id" xml:"id,attr"`
+	Title    string `json:"title" xml:"title,attr"`
+	Artist   string `json:"artist" xml:"artist,attr"`
+	Album    string `json:"album" xml:"album,attr"`
+	Duration int    `json:"duration" xml:"duration,attr"`
+	Track    int    `json:"track" xml:"track,attr"`
+	Year     int    `json:"year" xml:"year,attr"`
+	Genre    string `json:"genre" xml:"genre,attr"`
+	CoverArt string `json:"coverArt,omitempty" xml:"coverArt,attr,omitempty"`
+	Suffix   string `json:"suffix" xml:"suffix,attr"`
+	IsDir    bool   `json:"isDir" xml:"isDir,attr"`
+}
+
+type subsonicSearchResult3 struct {
+	Artist []subsonicArtist `json:"artist,omitempty" xml:"artist,omitempty"`
+	Album  []subsonicAlbum  `json:"album,omitempty" xml:"album,omitempty"`
+	Song   []subsonicSong   `json:"song,omitempty" xml:"song,omitempty"`
+}
+
+type subsonicPlaylists struct {
+	Playlist []subsonicPlaylist `json:"playlist" xml:"playlist"`
+}
+
+type subsonicPlaylist struct {
+	ID        string         `json:"id" xml:"id,attr"`
+	Name      string         `json:"name" xml:"name,attr"`
+	SongCount int            `json:"songCount" xml:"songCount,attr"`
+	Entry     []subsonicSong `json:"entry,omitempty" xml:"entry,omitempty"`
+}
+
+type subsonicError struct {
+	Code    int    `json:"code" xml:"code,attr"`
+	Message string `json:"message" xml:"message,attr"`
+}
+
+// Subsonic error codes, per the spec at subsonic.org/pages/api.jsp.
+const (
+	errGeneric            = 0
+	errMissingParam       = 10
+	errUnsupportedVersion = 20
+	errWrongCredentials   = 40
+	errNotAuthorized      = 50
+	errNotFound           = 70
+)
+
+// RegisterSubsonicRoutes mounts the Subsonic-compatible surface under /rest.
+func RegisterSubsonicRoutes(app *fiber.App, db *gorm.DB, tracker *PlaybackTracker) {
+	group := app.Group("/rest", subsonicAuthMiddleware(db))
+
+	group.Get("/ping.view", subsonicPing)
+	group.Get("/getLicense.view", subsonicGetLicense)
+	group.Get("/getMusicFolders.view", subsonicGetMusicFolders)
+	group.Get("/getIndexes.view", subsonicGetIndexes(db))
+	group.Get("/getArtists.view", subsonicGetArtists(db))
+	group.Get("/getAlbum.view", subsonicGetAlbum(db))
+	group.Get("/getSong.view", subsonicGetSong(db))
+	group.Get("/search3.view", subsonicSearch3(db))
+	group.Get("/getPlaylists.view", subsonicGetPlaylists(db))
+	group.Post("/createPlaylist.view", subsonicCreatePlaylist(db))
+	group.Get("/createPlaylist.view", subsonicCreatePlaylist(db))
+	group.Post("/updatePlaylist.view", subsonicUpdatePlaylist(db))
+	group.Get("/updatePlaylist.view", subsonicUpdatePlaylist(db))
+	group.Get("/stream.view", subsonicStream(db))
+	group.Get("/download.view", subsonicDownload(db))
+	group.Get("/getCoverArt.view", subsonicGetCoverArt(db))
+	group.Get("/scrobble.view", subsonicScrobble(db, tracker))
+}
+
+// subsonicAuthMiddleware validates either the salted-MD5 token scheme
+// (u, t, s) or the legacy cleartext password (u, p).
+func subsonicAuthMiddleware(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		username := c.Query("u")
+		if username == "" {
+			return writeSubsonicError(c, errMissingParam, "Required parameter is missing")
+		}
+
+		password, err := lookupSubsonicPassword(db, username)
+		if err != nil {
+			return writeSubsonicError(c, errWrongCredentials, "Wrong username or password")
+		}
+
+		if token := c.Query("t"); token != "" {
+			salt := c.Query("s")
+			expected := fmt.Sprintf("%x", md5.Sum([]byte(password+salt)))
+			if token != expected {
+				return writeSubsonicError(c, errWrongCredentials, "Wrong username or password")
+			}
+			return c.Next()
+		}
+
+		if p := c.Query("p"); p != "" {
+			plain := p
+			if len(p) > 4 && p[:4] == "enc:" {
+				plain = decodeSubsonicHexPassword(p[4:])
+			}
+			if plain != password {
+				return writeSubsonicError(c, errWrongCredentials, "Wrong username or password")
+			}
+			return c.Next()
+		}
+
+		return writeSubsonicError(c, errMissingParam, "Required parameter is missing")
+	}
+}
+
+func decodeSubsonicHexPassword(hex string) string {
+	b := make([]byte, len(hex)/2)
+	for i := range b {
+		fmt.Sscanf(hex[i*2:i*2+2], "%02x", &b[i])
+	}
+	return string(b)
+}
+
+func lookupSubsonicPassword(db *gorm.DB, username string) (string, error) {
+	var cred UserCredential
+	err := db.Where("user_id = ? AND service = ?", username, "subsonic").First(&cred).Error
+	return cred.SessionKey, err
+}
+
+// writeSubsonicResponse renders either XML or JSON depending on the f= param.
+func writeSubsonicResponse(c *fiber.Ctx, resp subsonicResponse) error {
+	resp.Status = "ok"
+	resp.Version = subsonicAPIVersion
+
+	if c.Query("f") == "json" {
+		return c.JSON(fiber.Map{"subsonic-response": resp})
+	}
+
+	c.Set("Content-Type", "text/xml; charset=utf-8")
+	return c.Status(http.StatusOK).XML(resp)
+}
+
+func writeSubsonicError(c *fiber.Ctx, code int, message string) error {
+	resp := subsonicResponse{
+		Status:  "failed",
+		Version: subsonicAPIVersion,
+		Error:   &subsonicError{Code: code, Message: message},
+	}
+
+	if c.Query("f") == "json" {
+		return c.JSON(fiber.Map{"subsonic-response": resp})
+	}
+
+	c.Set("Content-Type", "text/xml; charset=utf-8")
+	return c.Status(http.StatusOK).XML(resp)
+}
+
+// writeSubsonicErrorHTTP is writeSubsonicError for handlers bridged through
+// adaptor.HTTPHandlerFunc, which only have a plain http.ResponseWriter.
+func writeSubsonicErrorHTTP(w http.ResponseWriter, r *http.Request, code int, message string) {
+	resp := subsonicResponse{
+		Status:  "failed",
+		Version: subsonicAPIVersion,
+		Error:   &subsonicError{Code: code, Message: message},
+	}
+
+	if r.URL.Query().Get("f") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(fiber.Map{"subsonic-response": resp})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	xml.NewEncoder(w).Encode(resp)
+}
+
+func subsonicPing(c *fiber.Ctx) error {
+	return writeSubsonicResponse(c, subsonicResponse{})
+}
+
+func subsonicGetLicense(c *fiber.Ctx) error {
+	return writeSubsonicResponse(c, subsonicResponse{License: &subsonicLicense{Valid: true}})
+}
+
+func subsonicGetMusicFolders(c *fiber.Ctx) error {
+	return writeSubsonicResponse(c, subsonicResponse{
+		MusicFolders: &subsonicMusicFolders{
+			MusicFolder: []subsonicMusicFolder{{ID: 1, Name: "Music"}},
+		},
+	})
+}
+
+func subsonicGetIndexes(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var songs []Song
+		db.Find(&songs)
+
+		byLetter := map[string][]subsonicArtist{}
+		seen := map[string]bool{}
+		for _, s := range songs {
+			if s.Artist == "" || seen[s.Artist] {
+				continue
+			}
+			seen[s.Artist] = true
+			letter := "#"
+			if len(s.Artist) > 0 {
+				letter = string([]rune(s.Artist)[0])
+			}
+			byLetter[letter] = append(byLetter[letter], subsonicArtist{ID: s.Artist, Name: s.Artist})
+		}
+
+		var indexes []subsonicIndex
+		for letter, artists := range byLetter {
+			indexes = append(indexes, subsonicIndex{Name: letter, Artist: artists})
+		}
+
+		return writeSubsonicResponse(c, subsonicResponse{Indexes: &subsonicIndexes{Index: indexes}})
+	}
+}
+
+func subsonicGetArtists(db *gorm.DB) fiber.Handler {
+	// Same grouping and same response as getIndexes: the artist index is
+	// returned under "indexes", and the "artists" element is not populated.
+	return subsonicGetIndexes(db)
+}
+
+func subsonicGetAlbum(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		albumID := c.Query("id")
+
+		var songs []Song
+		db.Where("album = ?", albumID).Order("track_no").Find(&songs)
+		if len(songs) == 0 {
+			return writeSubsonicError(c, errNotFound, "Album not found")
+		}
+
+		return writeSubsonicResponse(c, subsonicResponse{
+			Album: &subsonicAlbum{
+				ID:     albumID,
+				Name:   albumID,
+				Artist: songs[0].Artist,
+				Song:   toSubsonicSongs(songs),
+			},
+		})
+	}
+}
+
+func subsonicGetSong(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var song Song
+		if err := db.First(&song, c.Query("id")).Error; err != nil {
+			return writeSubsonicError(c, errNotFound, "Song not found")
+		}
+		s := toSubsonicSong(song)
+		return writeSubsonicResponse(c, subsonicResponse{Song: &s})
+	}
+}
+
+func subsonicSearch3(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		query := "%" + c.Query("query") + "%"
+
+		var songs []Song
+		db.Where("name LIKE ? OR artist LIKE ? OR album LIKE ?", query, query, query).Limit(100).Find(&songs)
+
+		return writeSubsonicResponse(c, subsonicResponse{
+			SearchResult3: &subsonicSearchResult3{Song: toSubsonicSongs(songs)},
+		})
+	}
+}
+
+func subsonicGetPlaylists(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var playlists []Playlist
+		db.Preload("Songs").Find(&playlists)
+
+		out := make([]subsonicPlaylist, 0, len(playlists))
+		for _, p := range playlists {
+			out = append(out, subsonicPlaylist{
+				ID:        strconv.Itoa(int(p.ID)),
+				Name:      p.Name,
+				SongCount: len(p.Songs),
+			})
+		}
+
+		return writeSubsonicResponse(c, subsonicResponse{Playlists: &subsonicPlaylists{Playlist: out}})
+	}
+}
+
+func subsonicCreatePlaylist(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		name := c.Query("name")
+		playlist := Playlist{Name: name}
+
+		for _, idStr := range c.Context().QueryArgs().PeekMulti("songId") {
+			var song Song
+			if db.First(&song, string(idStr)).Error == nil {
+				playlist.Songs = append(playlist.Songs, song)
+			}
+		}
+
+		if err := db.Create(&playlist).Error; err != nil {
+			return writeSubsonicError(c, errGeneric, "Failed to create playlist")
+		}
+
+		return writeSubsonicResponse(c, subsonicResponse{
+			Playlist: &subsonicPlaylist{ID: strconv.Itoa(int(playlist.ID)), Name: playlist.Name, SongCount: len(playlist.Songs)},
+		})
+	}
+}
+
+func subsonicUpdatePlaylist(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Query("playlistId")
+		var playlist Playlist
+		if err := db.First(&playlist, id).Error; err != nil {
+			return writeSubsonicError(c, errNotFound, "Playlist not found")
+		}
+
+		if name := c.Query("name"); name != "" {
+			playlist.Name = name
+			db.Save(&playlist)
+		}
+
+		return writeSubsonicResponse(c, subsonicResponse{})
+	}
+}
+
+// subsonicStream ties into the transcoding pipeline so native Subsonic
+// clients get the same range/transcode behavior as the web UI. It's bridged
+// through adaptor.HTTPHandlerFunc, the same way streamSongFiberHandler in
+// stream_example.go reuses Stream's net/http signature.
+func subsonicStream(db *gorm.DB) fiber.Handler {
+	return adaptor.HTTPHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var song Song
+		if err := db.First(&song, r.URL.Query().Get("id")).Error; err != nil {
+			writeSubsonicErrorHTTP(w, r, errNotFound, "Song not found")
+			return
+		}
+
+		streamSong := StreamSong{ID: song.ID, File: song.File, Duration: song.Duration}
+		opts := StreamOptions{Format: r.URL.Query().Get("format")}
+		if br := r.URL.Query().Get("maxBitRate"); br != "" {
+			opts.Bitrate, _ = strconv.Atoi(br)
+		}
+
+		if err := Stream(w, r, streamSong, opts); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func subsonicDownload(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var song Song
+		if err := db.First(&song, c.Query("id")).Error; err != nil {
+			return writeSubsonicError(c, errNotFound, "Song not found")
+		}
+		return c.Download(filepath.Join(songsRoot, song.File))
+	}
+}
+
+func subsonicGetCoverArt(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var song Song
+		if err := db.First(&song, c.Query("id")).Error; err != nil || song.CoverArtPath == "" {
+			return writeSubsonicError(c, errNotFound, "Cover art not found")
+		}
+		return c.SendFile(song.CoverArtPath)
+	}
+}
+
+// subsonicScrobble maps to the same PlaybackTracker used by the WebSocket
+// handlers, so web, native, and Subsonic clients share one scrobble engine.
+func subsonicScrobble(db *gorm.DB, tracker *PlaybackTracker) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var song Song
+		if err := db.First(&song, c.Query("id")).Error; err != nil {
+			return writeSubsonicError(c, errNotFound, "Song not found")
+		}
+
+		submission := c.Query("submission") != "false"
+		track := Track{Artist: song.Artist, Title: song.Name, Album: song.Album, Duration: time.Duration(song.Duration) * time.Second}
+		userID := c.Query("u")
+
+		if submission {
+			tracker.OnPlay(userID, track, 0)
+			tracker.OnPause(userID, track.Duration)
+		} else {
+			tracker.OnPlay(userID, track, 0)
+		}
+
+		return writeSubsonicResponse(c, subsonicResponse{})
+	}
+}
+
+func toSubsonicSong(s Song) subsonicSong {
+	return subsonicSong{
+		ID:       strconv.Itoa(int(s.ID)),
+		Title:    s.Name,
+		Artist:   s.Artist,
+		Album:    s.Album,
+		Duration: s.Duration,
+		Track:    s.TrackNo,
+		Year:     s.Year,
+		Genre:    s.Genre,
+		CoverArt: s.CoverArtPath,
+		Suffix:   "mp3",
+	}
+}
+
+func toSubsonicSongs(songs []Song) []subsonicSong {
+	out := make([]subsonicSong, 0, len(songs))
+	for _, s := range songs {
+		out = append(out, toSubsonicSong(s))
+	}
+	return out
+}
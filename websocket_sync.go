@@ -8,10 +8,8 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/websocket/v2"
-	"github.com/gorilla/websocket"
 )
 
-
 // WebSocket message types
 type MessageType string
 
@@ -47,6 +45,16 @@ type Hub struct {
 	Broadcast  chan []byte
 	Register   chan *Client
 	Unregister chan *Client
+
+	// Tracker and LookupTrack are optional; when both are set, play/pause
+	// messages are fed into the scrobbler's PlaybackTracker.
+	Tracker     *PlaybackTracker
+	LookupTrack func(songID string) (Track, bool)
+
+	// Rooms holds the synchronized-playback group sessions; see
+	// room_sync_example.go. Messages whose type is one of the room-protocol
+	// types are routed to HandleRoomMessage instead of the dumb broadcast.
+	Rooms *RoomRegistry
 }
 
 // NewHub creates a new hub
@@ -56,6 +64,18 @@ func NewHub() *Hub {
 		Register:   make(chan *Client),
 		Unregister: make(chan *Client),
 		Clients:    make(map[*Client]bool),
+		Rooms:      NewRoomRegistry(),
+	}
+}
+
+// isRoomMessageType reports whether a WebSocket message's "type" field is
+// part of the room-sync protocol rather than the plain broadcast protocol.
+func isRoomMessageType(t string) bool {
+	switch RoomMessageType(t) {
+	case PingMessage, PongMessage, JoinRoomMessage, LeaveRoomMessage, TransferHostMessage, ResyncMessage, RoomStateMessage:
+		return true
+	default:
+		return false
 	}
 }
 
@@ -99,6 +119,20 @@ func (c *Client) readPump(hub *Hub) {
 			break
 		}
 
+		// Peek the message type to decide which protocol handles it.
+		var typePeek struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(message, &typePeek); err != nil {
+			log.Printf("error unmarshaling message: %v", err)
+			continue
+		}
+
+		if isRoomMessageType(typePeek.Type) {
+			hub.handleRoomMessage(c, message)
+			continue
+		}
+
 		// Process the message
 		var msg WebSocketMessage
 		if err := json.Unmarshal(message, &msg); err != nil {
@@ -111,12 +145,77 @@ func (c *Client) readPump(hub *Hub) {
 			msg.Timestamp = time.Now().Unix()
 		}
 
+		hub.trackScrobble(msg)
+
 		// Broadcast to all clients
 		broadcastMsg, _ := json.Marshal(msg)
 		hub.Broadcast <- broadcastMsg
 	}
 }
 
+// handleRoomMessage dispatches one room-protocol message through
+// HandleRoomMessage, sending the direct response (if any) back to the
+// originating client and the broadcast (if any) to every member of that
+// client's room, the originating client included.
+func (h *Hub) handleRoomMessage(c *Client, raw []byte) {
+	response, broadcast := HandleRoomMessage(h.Rooms, h, c, raw)
+
+	if response != nil {
+		select {
+		case c.Send <- response:
+		default:
+		}
+	}
+
+	if broadcast == nil {
+		return
+	}
+
+	var msg RoomMessage
+	if err := json.Unmarshal(raw, &msg); err != nil || msg.RoomID == "" {
+		return
+	}
+
+	room := h.Rooms.GetOrCreate(msg.RoomID, h)
+	room.mu.Lock()
+	members := make([]*Client, 0, len(room.Members))
+	for _, member := range room.Members {
+		members = append(members, member)
+	}
+	room.mu.Unlock()
+
+	for _, member := range members {
+		select {
+		case member.Send <- broadcast:
+		default:
+		}
+	}
+}
+
+// trackScrobble feeds play/pause messages into the scrobbler's
+// PlaybackTracker, if one is wired up via Hub.Tracker/Hub.LookupTrack.
+func (h *Hub) trackScrobble(msg WebSocketMessage) {
+	if h.Tracker == nil || h.LookupTrack == nil || msg.UserID == "" {
+		return
+	}
+
+	switch msg.Type {
+	case PlayMessage:
+		track, ok := h.LookupTrack(msg.SongID)
+		if !ok {
+			return
+		}
+		h.Tracker.OnPlay(msg.UserID, track, time.Duration(msg.Position*float64(time.Second)))
+	case PauseMessage:
+		track, ok := h.LookupTrack(msg.SongID)
+		if !ok {
+			return
+		}
+		h.Tracker.OnPlay(msg.UserID, track, 0) // ensure state exists if pause arrives first
+		h.Tracker.OnPause(msg.UserID, time.Duration(msg.Position*float64(time.Second)))
+	}
+}
+
 // writePump pumps messages from the hub to the websocket connection
 func (c *Client) writePump() {
 	defer func() {
@@ -198,25 +297,30 @@ func SendPauseSync(hub *Hub, userID string, position float64) {
 	hub.Broadcast <- data
 }
 
-// Example usage in a Fiber app
-func main() {
-	app := fiber.New()
-
-	// Create hub
-	hub := NewHub()
-	go hub.Run()
-
-	// Regular routes
-	app.Get("/", func(c *fiber.Ctx) error {
-		return c.SendString("Music Player WebSocket Sync Server")
+// SendLibraryEvent notifies connected clients that the library changed, e.g.
+// a new track was found or an existing one was updated during a scan.
+func SendLibraryEvent(hub *Hub, event string, payload interface{}) {
+	data, err := json.Marshal(struct {
+		Type    string      `json:"type"`
+		Event   string      `json:"event"`
+		Payload interface{} `json:"payload"`
+	}{
+		Type:    "library",
+		Event:   event,
+		Payload: payload,
 	})
+	if err != nil {
+		log.Printf("error marshaling library event: %v", err)
+		return
+	}
 
-	// WebSocket route
-	app.Use("/ws", websocketHandler)
-	app.Get("/ws/:user_id", ServeWebSocket(hub))
+	hub.Broadcast <- data
+}
 
-	// Example API endpoint that sends sync messages
-	app.Post("/api/sync/play", func(c *fiber.Ctx) error {
+// syncPlayHandler handles POST /api/sync/play, letting a client trigger a
+// play-sync broadcast over REST instead of the WebSocket connection.
+func syncPlayHandler(hub *Hub) fiber.Handler {
+	return func(c *fiber.Ctx) error {
 		var req struct {
 			UserID   string  `json:"user_id"`
 			SongID   string  `json:"song_id"`
@@ -227,11 +331,8 @@ func main() {
 			return c.Status(400).JSON(fiber.Map{"error": "Cannot parse JSON"})
 		}
 
-		// Send play sync message
 		SendPlaySync(hub, req.UserID, req.SongID, req.Position)
 
 		return c.JSON(fiber.Map{"message": "Play sync sent"})
-	})
-
-	log.Fatal(app.Listen(":8080"))
+	}
 }